@@ -0,0 +1,182 @@
+package harness
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Access is an entry of a permission matrix given to AssertAccess.
+type Access struct {
+	// Verb is the API verb, eg. "get", "list" or "create".
+	Verb string
+	// Resource is the resource, in the form accepted by kubectl auth can-i:
+	// "pods", "deployments.apps" or "pods/log" for a subresource.
+	Resource string
+	// Namespace is the namespace of the request, empty for cluster-scoped
+	// resources or requests across all namespaces.
+	Namespace string
+	// Allowed is whether the request is expected to be allowed.
+	Allowed bool
+}
+
+func (a Access) String() string {
+	s := a.Verb + " " + a.Resource
+	if a.Namespace != "" {
+		s += " in namespace " + a.Namespace
+	}
+	return s
+}
+
+// resourceAttributes parses resource[.group][/subresource].
+func resourceAttributes(verb, resource, namespace string) *authorizationv1.ResourceAttributes {
+	attrs := &authorizationv1.ResourceAttributes{
+		Verb:      verb,
+		Namespace: namespace,
+	}
+	if i := strings.Index(resource, "/"); i >= 0 {
+		attrs.Subresource = resource[i+1:]
+		resource = resource[:i]
+	}
+	if i := strings.Index(resource, "."); i >= 0 {
+		attrs.Group = resource[i+1:]
+		resource = resource[:i]
+	}
+	attrs.Resource = resource
+	return attrs
+}
+
+// subjectUser returns the user name and groups of an RBAC subject.
+func subjectUser(subject rbacv1.Subject) (string, []string, error) {
+	switch subject.Kind {
+	case rbacv1.UserKind:
+		return subject.Name, nil, nil
+	case rbacv1.GroupKind:
+		return "", []string{subject.Name}, nil
+	case rbacv1.ServiceAccountKind:
+		user, groups := serviceAccountUser(&v1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Namespace: subject.Namespace, Name: subject.Name},
+		})
+		return user, groups, nil
+	default:
+		return "", nil, fmt.Errorf("unknown subject kind %q", subject.Kind)
+	}
+}
+
+func serviceAccountSubject(sa *v1.ServiceAccount) rbacv1.Subject {
+	return rbacv1.Subject{
+		Kind:      rbacv1.ServiceAccountKind,
+		Namespace: sa.Namespace,
+		Name:      sa.Name,
+	}
+}
+
+// can returns whether subject is allowed the request.
+func (test *Test) can(subject rbacv1.Subject, verb, resource, namespace string) (bool, error) {
+	user, groups, err := subjectUser(subject)
+	if err != nil {
+		return false, err
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               user,
+			Groups:             groups,
+			ResourceAttributes: resourceAttributes(verb, resource, namespace),
+		},
+	}
+	review, err = test.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("access review of %s failed: %w", subject.Name, err)
+	}
+	if review.Status.EvaluationError != "" {
+		test.Debugf("access review of %s: %s", subject.Name, review.Status.EvaluationError)
+	}
+	return review.Status.Allowed, nil
+}
+
+func (test *Test) assertAccess(subject rbacv1.Subject, matrix []Access) error {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 1, ' ', 0)
+
+	fmt.Fprintln(tw, "VERB\t  RESOURCE\t  NAMESPACE\t  EXPECTED\t  ACTUAL")
+	mismatches := 0
+	for _, access := range matrix {
+		allowed, err := test.can(subject, access.Verb, access.Resource, access.Namespace)
+		if err != nil {
+			return err
+		}
+		if allowed == access.Allowed {
+			continue
+		}
+		mismatches++
+		fmt.Fprintf(tw, "%s\t  %s\t  %s\t  %s\t  %s\n",
+			access.Verb, access.Resource, access.Namespace,
+			reachableString(access.Allowed), reachableString(allowed))
+	}
+	tw.Flush()
+
+	if mismatches == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s %s: unexpected permissions:\n%s", strings.ToLower(subject.Kind), subject.Name, buf.String())
+}
+
+// AssertSubjectAccess checks the permissions of an RBAC subject, eg. one of the
+// subjects of a ClusterRoleBinding, against a permission matrix. All the
+// entries of the matrix are checked and the test fails with a table of the
+// mismatches.
+func (test *Test) AssertSubjectAccess(subject rbacv1.Subject, matrix []Access) {
+	test.err(test.assertAccess(subject, matrix))
+}
+
+// AssertAccess checks the permissions of a service account against a
+// permission matrix. All the entries of the matrix are checked and the test
+// fails with a table of the mismatches. Permissions are checked with
+// SubjectAccessReviews, so they are evaluated by the API server authorizers
+// without the need to impersonate the service account.
+func (test *Test) AssertAccess(sa *v1.ServiceAccount, matrix []Access) {
+	test.err(test.assertAccess(serviceAccountSubject(sa), matrix))
+}
+
+// AssertCan checks a service account is allowed to perform verb on resource in
+// namespace. resource can name an API group and a subresource, eg.
+// "deployments.apps" or "pods/log".
+func (test *Test) AssertCan(sa *v1.ServiceAccount, verb, resource, namespace string) {
+	test.AssertAccess(sa, []Access{{verb, resource, namespace, true}})
+}
+
+// AssertCannot checks a service account isn't allowed to perform verb on
+// resource in namespace.
+func (test *Test) AssertCannot(sa *v1.ServiceAccount, verb, resource, namespace string) {
+	test.AssertAccess(sa, []Access{{verb, resource, namespace, false}})
+}
+
+func (test *Test) selfCan(verb, resource, namespace string) (bool, error) {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: resourceAttributes(verb, resource, namespace),
+		},
+	}
+	review, err := test.kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("self access review failed: %w", err)
+	}
+	return review.Status.Allowed, nil
+}
+
+// Can returns whether the identity making the test API calls is allowed to
+// perform verb on resource in namespace. On a view created with As or
+// AsServiceAccount, this is the impersonated identity.
+func (test *Test) Can(verb, resource, namespace string) bool {
+	allowed, err := test.selfCan(verb, resource, namespace)
+	test.err(err)
+	return allowed
+}
@@ -0,0 +1,45 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestResourceAttributes(t *testing.T) {
+	tests := []struct {
+		resource string
+		expected authorizationv1.ResourceAttributes
+	}{
+		{"pods", authorizationv1.ResourceAttributes{Verb: "get", Namespace: "ns", Resource: "pods"}},
+		{"pods/log", authorizationv1.ResourceAttributes{Verb: "get", Namespace: "ns", Resource: "pods", Subresource: "log"}},
+		{"deployments.apps", authorizationv1.ResourceAttributes{Verb: "get", Namespace: "ns", Resource: "deployments", Group: "apps"}},
+		{"deployments.apps/scale", authorizationv1.ResourceAttributes{Verb: "get", Namespace: "ns", Resource: "deployments", Group: "apps", Subresource: "scale"}},
+		{"ingresses.networking.k8s.io", authorizationv1.ResourceAttributes{Verb: "get", Namespace: "ns", Resource: "ingresses", Group: "networking.k8s.io"}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, &test.expected, resourceAttributes("get", test.resource, "ns"), test.resource)
+	}
+}
+
+func TestSubjectUser(t *testing.T) {
+	user, groups, err := subjectUser(rbacv1.Subject{Kind: rbacv1.UserKind, Name: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", user)
+	assert.Empty(t, groups)
+
+	user, groups, err = subjectUser(rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "dev"})
+	assert.NoError(t, err)
+	assert.Equal(t, "", user)
+	assert.Equal(t, []string{"dev"}, groups)
+
+	user, _, err = subjectUser(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "ns", Name: "app"})
+	assert.NoError(t, err)
+	assert.Equal(t, "system:serviceaccount:ns:app", user)
+
+	_, _, err = subjectUser(rbacv1.Subject{Kind: "Robot"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package harness
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Condition is a function polled by Eventually and Consistently. It returns
+// whether the condition holds as well as the value observed when evaluating
+// it. That value is included in the test failure message.
+type Condition func() (ok bool, observed interface{})
+
+const conditionPollInterval = time.Second
+
+func eventually(cond Condition, timeout, interval time.Duration) error {
+	var last interface{}
+	err := wait.PollImmediate(interval, timeout, func() (bool, error) {
+		ok, observed := cond()
+		last = observed
+		return ok, nil
+	})
+	if err != nil {
+		return fmt.Errorf("condition not met after %v, last observed value: %+v", timeout, last)
+	}
+	return nil
+}
+
+func consistently(cond Condition, duration, interval time.Duration) error {
+	start := time.Now()
+	for {
+		ok, observed := cond()
+		if !ok {
+			return fmt.Errorf("condition stopped holding after %v, observed value: %+v", time.Since(start).Round(time.Millisecond), observed)
+		}
+		if time.Since(start) >= duration {
+			return nil
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Eventually polls cond every second until it holds. The test fails if cond
+// still doesn't hold after timeout, reporting the last observed value.
+func (test *Test) Eventually(cond Condition, timeout time.Duration) {
+	test.t.Helper()
+	test.err(eventually(cond, timeout, conditionPollInterval))
+}
+
+// Consistently polls cond every second for duration. The test fails as soon
+// as cond doesn't hold, reporting the observed value.
+func (test *Test) Consistently(cond Condition, duration time.Duration) {
+	test.t.Helper()
+	test.err(consistently(cond, duration, conditionPollInterval))
+}
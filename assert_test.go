@@ -0,0 +1,43 @@
+package harness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func counter(threshold int) Condition {
+	n := 0
+	return func() (bool, interface{}) {
+		n++
+		return n < threshold, n
+	}
+}
+
+func TestEventually(t *testing.T) {
+	n := 0
+	err := eventually(func() (bool, interface{}) {
+		n++
+		return n == 3, n
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	err = eventually(func() (bool, interface{}) {
+		return false, "not ready"
+	}, 10*time.Millisecond, time.Millisecond)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not ready")
+}
+
+func TestConsistently(t *testing.T) {
+	err := consistently(func() (bool, interface{}) {
+		return true, nil
+	}, 10*time.Millisecond, time.Millisecond)
+	assert.NoError(t, err)
+
+	err = consistently(counter(3), time.Second, time.Millisecond)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "observed value: 3")
+}
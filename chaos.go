@@ -0,0 +1,303 @@
+package harness
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"text/tabwriter"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// recoveryPollInterval is how often the state of a workload is checked while
+// measuring its recovery. It's the resolution of the recovery times.
+const recoveryPollInterval = 250 * time.Millisecond
+
+// KillPodsOptions are options for KillPods.
+type KillPodsOptions struct {
+	// PodNames are the names of the pods to kill. When empty, count random
+	// pods of the workload are killed. Only StatefulSet pods keep their name
+	// when replaced, PodNames can't be combined with Duration for other
+	// workloads.
+	PodNames []string
+	// GracePeriodZero deletes the pods without grace period, simulating a
+	// crash rather than a graceful shutdown.
+	GracePeriodZero bool
+	// Duration, when non zero, repeats kill rounds until it has elapsed. A
+	// round starts once the workload has recovered from the previous one.
+	Duration time.Duration
+	// Interval is how long to wait between rounds.
+	Interval time.Duration
+	// Timeout is how long to wait for the workload to recover from each
+	// round. Defaults to 5 minutes.
+	Timeout time.Duration
+}
+
+// KillRound is the recovery measurement of one round of pod kills.
+type KillRound struct {
+	// Killed are the names of the pods killed in this round.
+	Killed []string
+	// KilledAt is when the pods were deleted.
+	KilledAt time.Time
+	// TimeToScheduled is how long it took for all the replacement pods to be
+	// scheduled on a node.
+	TimeToScheduled time.Duration
+	// TimeToReady is how long it took for the workload to be fully ready
+	// again, ie. its desired number of pods are ready, replacements included.
+	TimeToReady time.Duration
+}
+
+// RecoveryReport is the result of KillPods.
+type RecoveryReport struct {
+	// Workload is the name of the workload whose pods have been killed.
+	Workload string
+	Rounds   []KillRound
+}
+
+// MaxTimeToScheduled returns the longest time to schedule the replacement pods
+// across rounds.
+func (r *RecoveryReport) MaxTimeToScheduled() time.Duration {
+	var max time.Duration
+	for _, round := range r.Rounds {
+		if round.TimeToScheduled > max {
+			max = round.TimeToScheduled
+		}
+	}
+	return max
+}
+
+// MaxTimeToReady returns the longest recovery time across rounds.
+func (r *RecoveryReport) MaxTimeToReady() time.Duration {
+	var max time.Duration
+	for _, round := range r.Rounds {
+		if round.TimeToReady > max {
+			max = round.TimeToReady
+		}
+	}
+	return max
+}
+
+// String returns the report as a table with a row per round.
+func (r *RecoveryReport) String() string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 1, ' ', 0)
+
+	fmt.Fprintln(tw, "ROUND\t  KILLED\t  SCHEDULED\t  READY")
+	for i, round := range r.Rounds {
+		fmt.Fprintf(tw, "%d\t  %v\t  %s\t  %s\n", i+1, round.Killed, round.TimeToScheduled, round.TimeToReady)
+	}
+	tw.Flush()
+	return buf.String()
+}
+
+// workloadPods returns the pods of owner and the number of pods owner wants.
+func (test *Test) workloadPods(owner runtime.Object) ([]v1.Pod, int, error) {
+	switch o := owner.(type) {
+	case *appsv1.Deployment:
+		d, err := test.GetDeployment(o.Namespace, o.Name)
+		if err != nil {
+			return nil, 0, err
+		}
+		pl, err := test.listPodsFromDeployment(d)
+		if err != nil {
+			return nil, 0, err
+		}
+		replicas := 1
+		if d.Spec.Replicas != nil {
+			replicas = int(*d.Spec.Replicas)
+		}
+		return pl.Items, replicas, nil
+	case *appsv1.StatefulSet:
+		s, err := test.GetStatefulSet(o.Namespace, o.Name)
+		if err != nil {
+			return nil, 0, err
+		}
+		pl, err := test.listPodsFromStatefulSet(s)
+		if err != nil {
+			return nil, 0, err
+		}
+		return pl.Items, statefulSetReplicas(s), nil
+	case *appsv1.DaemonSet:
+		d, err := test.GetDaemonSet(o.Namespace, o.Name)
+		if err != nil {
+			return nil, 0, err
+		}
+		pl, err := test.listPodsFromDaemonSet(d)
+		if err != nil {
+			return nil, 0, err
+		}
+		return pl.Items, int(d.Status.DesiredNumberScheduled), nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported workload type %T", owner)
+	}
+}
+
+// pickPods returns the pods to kill: the pods named in names or count random
+// running pods.
+func pickPods(pods []v1.Pod, count int, names []string) ([]v1.Pod, error) {
+	candidates := []v1.Pod{}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp == nil {
+			candidates = append(candidates, pod)
+		}
+	}
+
+	if len(names) > 0 {
+		picked := []v1.Pod{}
+		for _, name := range names {
+			found := false
+			for _, pod := range candidates {
+				if pod.Name == name {
+					picked = append(picked, pod)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("pod %s not found", name)
+			}
+		}
+		return picked, nil
+	}
+
+	if count > len(candidates) {
+		return nil, fmt.Errorf("can't kill %d pods, only %d running", count, len(candidates))
+	}
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	return candidates[:count], nil
+}
+
+// recoveryState returns whether the replacements of the killed pods have been
+// scheduled and whether the workload is fully ready again. before is the set
+// of pods that existed before the kill.
+func (test *Test) recoveryState(pods []v1.Pod, before map[types.UID]bool, killed, replicas int) (scheduled, ready bool) {
+	replacements, scheduledReplacements, numReady, alive := 0, 0, 0, 0
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		alive++
+		if !before[pod.UID] {
+			replacements++
+			if pod.Spec.NodeName != "" {
+				scheduledReplacements++
+			}
+		}
+		if ok, _ := test.PodReady(pod); ok {
+			numReady++
+		}
+	}
+
+	scheduled = replacements >= killed && scheduledReplacements == replacements
+	ready = scheduled && alive == replicas && numReady == replicas
+	return
+}
+
+func (test *Test) killRound(owner runtime.Object, count int, opts *KillPodsOptions) (*KillRound, error) {
+	pods, _, err := test.workloadPods(owner)
+	if err != nil {
+		return nil, err
+	}
+	victims, err := pickPods(pods, count, opts.PodNames)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", objectName(owner), err)
+	}
+
+	before := make(map[types.UID]bool)
+	for _, pod := range pods {
+		before[pod.UID] = true
+	}
+
+	deleteOptions := metav1.DeleteOptions{}
+	if opts.GracePeriodZero {
+		var zero int64
+		deleteOptions.GracePeriodSeconds = &zero
+	}
+
+	round := &KillRound{KilledAt: time.Now()}
+	for i := range victims {
+		test.Debugf("killing pod %s", victims[i].Name)
+		if err := test.deletePodWithOptions(&victims[i], deleteOptions); err != nil {
+			return nil, err
+		}
+		round.Killed = append(round.Killed, victims[i].Name)
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Minute
+	}
+	err = wait.Poll(recoveryPollInterval, timeout, func() (bool, error) {
+		pods, replicas, err := test.workloadPods(owner)
+		if err != nil {
+			return false, err
+		}
+		scheduled, ready := test.recoveryState(pods, before, len(victims), replicas)
+		if scheduled && round.TimeToScheduled == 0 {
+			round.TimeToScheduled = time.Since(round.KilledAt)
+		}
+		if ready {
+			round.TimeToReady = time.Since(round.KilledAt)
+		}
+		return ready, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s hasn't recovered from killing %v: %w", objectName(owner), round.Killed, err)
+	}
+
+	test.Debugf("%s recovered in %s", objectName(owner), round.TimeToReady)
+	return round, nil
+}
+
+func (test *Test) killPods(owner runtime.Object, count int, opts *KillPodsOptions) (*RecoveryReport, error) {
+	if opts == nil {
+		opts = &KillPodsOptions{}
+	}
+	// The named pods are gone after the first round, except for StatefulSets
+	// recreating pods with the same name.
+	if _, ok := owner.(*appsv1.StatefulSet); !ok && len(opts.PodNames) > 0 && opts.Duration > 0 {
+		return nil, fmt.Errorf("%s: PodNames can only be repeated with Duration for a StatefulSet", objectName(owner))
+	}
+
+	if err := test.needsControllers("KillPods"); err != nil {
+		return nil, err
+	}
+
+	report := &RecoveryReport{Workload: objectName(owner)}
+	start := time.Now()
+	for {
+		round, err := test.killRound(owner, count, opts)
+		if err != nil {
+			return report, err
+		}
+		report.Rounds = append(report.Rounds, *round)
+
+		if time.Since(start)+opts.Interval >= opts.Duration {
+			return report, nil
+		}
+		time.Sleep(opts.Interval)
+	}
+}
+
+// KillPods deletes count random pods, or the pods named in opts, of a
+// Deployment, StatefulSet or DaemonSet and measures how long the workload takes
+// to recover: how long until the replacement pods are scheduled and how long
+// until the workload has its desired number of ready pods again. opts can be
+// nil.
+//
+// When opts.Duration is set, kill rounds are repeated until it has elapsed.
+// The test fails if the workload doesn't recover from a round within
+// opts.Timeout.
+func (test *Test) KillPods(owner runtime.Object, count int, opts *KillPodsOptions) *RecoveryReport {
+	report, err := test.killPods(owner, count, opts)
+	test.err(err)
+	return report
+}
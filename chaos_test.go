@@ -0,0 +1,118 @@
+package harness
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func chaosPod(name string, ready bool) v1.Pod {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)},
+		Spec:       v1.PodSpec{NodeName: "node"},
+		Status: v1.PodStatus{
+			Phase:      v1.PodRunning,
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: status}},
+		},
+	}
+}
+
+func TestPickPods(t *testing.T) {
+	terminating := chaosPod("c", true)
+	terminating.DeletionTimestamp = &metav1.Time{}
+	pods := []v1.Pod{chaosPod("a", true), chaosPod("b", true), terminating}
+
+	picked, err := pickPods(pods, 2, nil)
+	assert.NoError(t, err)
+	assert.Len(t, picked, 2)
+	for _, pod := range picked {
+		assert.NotEqual(t, "c", pod.Name)
+	}
+
+	_, err = pickPods(pods, 3, nil)
+	assert.Error(t, err)
+
+	picked, err = pickPods(pods, 0, []string{"b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "b", picked[0].Name)
+
+	_, err = pickPods(pods, 0, []string{"c"})
+	assert.Error(t, err)
+}
+
+func TestRecoveryState(t *testing.T) {
+	before := map[types.UID]bool{"a": true, "b": true}
+	killed := chaosPod("b", true)
+	killed.DeletionTimestamp = &metav1.Time{}
+	unscheduled := chaosPod("d", false)
+	unscheduled.Spec.NodeName = ""
+
+	tests := []struct {
+		name      string
+		pods      []v1.Pod
+		scheduled bool
+		ready     bool
+	}{
+		{"no replacement", []v1.Pod{chaosPod("a", true), killed}, false, false},
+		{"replacement pending", []v1.Pod{chaosPod("a", true), unscheduled}, false, false},
+		{"replacement not ready", []v1.Pod{chaosPod("a", true), chaosPod("d", false)}, true, false},
+		{"killed pod terminating", []v1.Pod{chaosPod("a", true), killed, chaosPod("d", true)}, true, true},
+		{"recovered", []v1.Pod{chaosPod("a", true), chaosPod("d", true)}, true, true},
+	}
+
+	test := &Test{}
+	for _, tt := range tests {
+		scheduled, ready := test.recoveryState(tt.pods, before, 1, 2)
+		assert.Equal(t, tt.scheduled, scheduled, tt.name)
+		assert.Equal(t, tt.ready, ready, tt.name)
+	}
+}
+
+func TestRecoveryReport(t *testing.T) {
+	report := &RecoveryReport{
+		Rounds: []KillRound{
+			{Killed: []string{"a"}, TimeToScheduled: time.Second, TimeToReady: 3 * time.Second},
+			{Killed: []string{"b"}, TimeToScheduled: 2 * time.Second, TimeToReady: 2 * time.Second},
+		},
+	}
+	assert.Equal(t, 2*time.Second, report.MaxTimeToScheduled())
+	assert.Equal(t, 3*time.Second, report.MaxTimeToReady())
+	assert.Contains(t, report.String(), "[a]")
+}
+
+func TestKillPodsNamedRepeated(t *testing.T) {
+	test := &Test{harness: &Harness{options: Options{ControlPlaneOnly: true}}}
+	meta := metav1.ObjectMeta{Namespace: "ns", Name: "app"}
+	opts := &KillPodsOptions{PodNames: []string{"app-0"}, Duration: time.Minute}
+
+	tests := []struct {
+		name  string
+		owner runtime.Object
+		valid bool
+	}{
+		{"deployment", &appsv1.Deployment{ObjectMeta: meta}, false},
+		{"daemonset", &appsv1.DaemonSet{ObjectMeta: meta}, false},
+		{"statefulset", &appsv1.StatefulSet{ObjectMeta: meta}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := test.killPods(tt.owner, 1, opts)
+			// Valid options get as far as checking the cluster runs
+			// controllers.
+			var noControllers *NoControllersError
+			assert.Equal(t, tt.valid, errors.As(err, &noControllers), "unexpected error: %v", err)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package harness
+
+import "fmt"
+
+// homeTest returns the test cluster views are attached to.
+func (test *Test) homeTest() *Test {
+	r := test.root()
+	if r.home != nil {
+		return r.home
+	}
+	return r
+}
+
+func (test *Test) cluster(name string) (*Test, error) {
+	home := test.homeTest()
+	if view, ok := home.clusters[name]; ok {
+		return view, nil
+	}
+
+	c, ok := test.harness.clusters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown cluster %s", name)
+	}
+
+	view := &Test{
+		ID:          home.ID,
+		Namespace:   home.Namespace,
+		harness:     home.harness,
+		t:           home.t,
+		logger:      home.logger,
+		clusterName: name,
+		home:        home,
+	}
+
+	var err error
+	view.restConfig, view.kubeClient, err = view.withRetries(c.restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s: %w", name, err)
+	}
+
+	if home.clusters == nil {
+		home.clusters = make(map[string]*Test)
+	}
+	home.clusters[name] = view
+
+	view.Infof("using API server %s for cluster %s", c.apiServer, name)
+
+	return view, nil
+}
+
+// Cluster returns a view of the test targeting one of the additional clusters
+// registered with Options.Clusters or Harness.AddCluster. All the test helpers
+// called on the view act on that cluster.
+//
+// The view has its own namespaces and cleanup: objects created in the cluster
+// are deleted, and the cluster state is dumped on failure, when the test is
+// closed. The view has the same Namespace name as the test but, as for the
+// test itself, call Setup on the view to create it in the cluster:
+//
+//	east := test.Cluster("east").Setup()
+//	east.CreateDeploymentFromFile(east.Namespace, "app.yaml")
+//
+// Calling Cluster several times with the same name returns the same view.
+func (test *Test) Cluster(name string) *Test {
+	view, err := test.cluster(name)
+	test.err(err)
+	return view
+}
@@ -0,0 +1,71 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/dlespiau/kube-test-harness/logger"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestCluster(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	defer func(saved func(*rest.Config) (kubernetes.Interface, error)) { newClient = saved }(newClient)
+	newClient = func(*rest.Config) (kubernetes.Interface, error) { return client, nil }
+
+	h := &Harness{
+		options: Options{AllowPodRestarts: true},
+		clusters: map[string]*cluster{
+			"east": {
+				restConfig: &rest.Config{Host: "https://east:6443"},
+				apiServer:  "https://east:6443",
+			},
+		},
+	}
+	test := &Test{
+		ID:        "cluster",
+		Namespace: "cluster-ns-1",
+		harness:   h,
+		t:         t,
+		logger:    (&logger.TestLogger{}).ForTest(t),
+	}
+
+	east, err := test.cluster("east")
+	assert.NoError(t, err)
+	assert.Equal(t, "east", east.clusterName)
+	assert.Equal(t, test.Namespace, east.Namespace)
+	assert.Equal(t, client, east.kubeClient)
+	assert.Equal(t, "https://east:6443", east.restConfig.Host)
+
+	again, err := test.cluster("east")
+	assert.NoError(t, err)
+	assert.Equal(t, east, again)
+
+	_, err = test.cluster("west")
+	assert.Error(t, err)
+
+	// Cluster views have their own state, attached to the test they have been
+	// created from.
+	var closed []string
+	east.addNamespace("east-ns")
+	east.addFinalizer(func() error { closed = append(closed, "east"); return nil })
+	test.addFinalizer(func() error { closed = append(closed, "test"); return nil })
+	assert.Empty(t, test.namespaces)
+	assert.Equal(t, []string{"east-ns"}, east.namespaces)
+
+	alice, err := east.as("alice", nil)
+	assert.NoError(t, err)
+	alice.addNamespace("alice-ns")
+	assert.Equal(t, []string{"east-ns", "alice-ns"}, east.namespaces)
+	fromAlice, err := alice.cluster("east")
+	assert.NoError(t, err)
+	assert.Equal(t, east, fromAlice)
+
+	east.Close()
+	assert.Empty(t, closed)
+
+	test.Close()
+	assert.Equal(t, []string{"east", "test"}, closed)
+}
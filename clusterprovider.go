@@ -0,0 +1,86 @@
+package harness
+
+import (
+	"fmt"
+
+	"github.com/dlespiau/kube-test-harness/logger"
+)
+
+// ClusterProvider manages the lifecycle of the cluster tests run against. When
+// Options.ClusterProvider is given, Setup creates the cluster and Close deletes
+// it. The provider package has implementations for kind, minikube, k3d and
+// existing clusters.
+type ClusterProvider interface {
+	// Create creates the cluster and waits for its API server to be up.
+	Create() error
+	// Kubeconfig returns the path to a kubeconfig file giving access to the
+	// cluster. It returns an error if the cluster doesn't exist.
+	Kubeconfig() (string, error)
+	// Delete deletes the cluster.
+	Delete() error
+	// LoadImage makes a container image of the local container runtime
+	// available to the cluster nodes, so pods can use it without pulling it
+	// from a registry.
+	LoadImage(image string) error
+}
+
+// createCluster creates the cluster of the provider, or reuses the existing
+// one, and points the harness at it.
+func (h *Harness) createCluster() error {
+	provider := h.options.ClusterProvider
+	if provider == nil {
+		return nil
+	}
+	if cp, ok := provider.(controlPlaneProvider); ok && cp.ControlPlaneOnly() {
+		h.options.ControlPlaneOnly = true
+	}
+
+	if h.options.ReuseCluster {
+		if kubeconfig, err := provider.Kubeconfig(); err == nil {
+			h.options.Logger.Logf(logger.Info, "reusing existing cluster")
+			h.options.Kubeconfig = kubeconfig
+			return nil
+		}
+	}
+
+	h.options.Logger.Logf(logger.Info, "creating cluster")
+	if err := provider.Create(); err != nil {
+		return fmt.Errorf("failed to create cluster: %w", err)
+	}
+	h.clusterCreated = true
+
+	kubeconfig, err := provider.Kubeconfig()
+	if err != nil {
+		return fmt.Errorf("failed to get cluster kubeconfig: %w", err)
+	}
+	h.options.Kubeconfig = kubeconfig
+	return nil
+}
+
+// deleteCluster deletes the cluster created by createCluster.
+func (h *Harness) deleteCluster() error {
+	if !h.clusterCreated || h.options.KeepCluster {
+		return nil
+	}
+
+	h.options.Logger.Logf(logger.Info, "deleting cluster")
+	if err := h.options.ClusterProvider.Delete(); err != nil {
+		return fmt.Errorf("failed to delete cluster: %w", err)
+	}
+	h.clusterCreated = false
+	return nil
+}
+
+// LoadImage makes a container image of the local container runtime available
+// to the cluster nodes. It needs Options.ClusterProvider.
+func (h *Harness) LoadImage(image string) error {
+	if h.options.ClusterProvider == nil {
+		return fmt.Errorf("loading image %s: no cluster provider", image)
+	}
+
+	h.options.Logger.Logf(logger.Info, "loading image %s", image)
+	if err := h.options.ClusterProvider.LoadImage(image); err != nil {
+		return fmt.Errorf("loading image %s failed: %w", image, err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package harness
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dlespiau/kube-test-harness/logger"
+	"github.com/dlespiau/kube-test-harness/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: secret
+`
+
+func writeTestKubeconfig(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "harness")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "kubeconfig")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(testKubeconfig), 0600))
+	return path
+}
+
+func TestClusterProviderLifecycle(t *testing.T) {
+	fake := &provider.Fake{Path: writeTestKubeconfig(t)}
+	h := New(Options{
+		ClusterProvider: fake,
+		Images:          []string{"app:dev"},
+		Logger:          &logger.PrintfLogger{},
+	})
+
+	code := h.run(func() int {
+		assert.Equal(t, 1, fake.Creates)
+		assert.Equal(t, []string{"app:dev"}, fake.Images)
+		assert.Equal(t, "https://127.0.0.1:6443", h.apiServer)
+		return 0
+	})
+	assert.Equal(t, 0, code)
+	assert.Equal(t, 1, fake.Deletes)
+}
+
+func TestClusterProviderReuse(t *testing.T) {
+	fake := &provider.Fake{Path: writeTestKubeconfig(t), Exists: true}
+	h := New(Options{
+		ClusterProvider: fake,
+		ReuseCluster:    true,
+		Logger:          &logger.PrintfLogger{},
+	})
+
+	assert.Equal(t, 0, h.run(func() int { return 0 }))
+	assert.Equal(t, 0, fake.Creates)
+	assert.Equal(t, 0, fake.Deletes)
+	assert.True(t, fake.Exists)
+}
+
+func TestClusterProviderKeep(t *testing.T) {
+	fake := &provider.Fake{Path: writeTestKubeconfig(t)}
+	h := New(Options{
+		ClusterProvider: fake,
+		KeepCluster:     true,
+		Logger:          &logger.PrintfLogger{},
+	})
+
+	assert.Equal(t, 0, h.run(func() int { return 0 }))
+	assert.Equal(t, 1, fake.Creates)
+	assert.Equal(t, 0, fake.Deletes)
+}
+
+func TestClusterProviderSetupFailure(t *testing.T) {
+	fake := &provider.Fake{CreateError: errors.New("no docker")}
+	h := New(Options{
+		ClusterProvider: fake,
+		Logger:          &logger.PrintfLogger{},
+	})
+
+	assert.Equal(t, 1, h.run(func() int {
+		t.Fatal("tests shouldn't run")
+		return 0
+	}))
+	assert.Equal(t, 0, fake.Deletes)
+}
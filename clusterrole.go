@@ -6,6 +6,7 @@ import (
 	"time"
 
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/util/yaml"
@@ -14,19 +15,24 @@ import (
 func (test *Test) createClusterRole(cr *rbacv1.ClusterRole) error {
 	test.Debugf("creating cluster role %s", cr.Name)
 
-	if _, err := test.harness.kubeClient.RbacV1().ClusterRoles().Create(context.TODO(), cr, metav1.CreateOptions{}); err != nil {
+	if _, err := test.kubeClient.RbacV1().ClusterRoles().Create(context.TODO(), cr, metav1.CreateOptions{}); err != nil {
 		return fmt.Errorf("failed to create cluster role %s: %w", cr.Name, err)
 	}
 	return nil
 }
 
-// CreateClusterRole creates a cluster role.
+// CreateClusterRole creates a cluster role. Contrary to namespaced objects,
+// cluster-scoped objects aren't deleted with the test namespace: the cluster
+// role is deleted when the test is closed.
 func (test *Test) CreateClusterRole(cr *rbacv1.ClusterRole) {
-	err := test.createClusterRole(cr)
-	test.err(err)
+	if err := test.createClusterRole(cr); err != nil {
+		test.err(err)
+		return
+	}
 
+	root := test.root()
 	test.addFinalizer(func() error {
-		if err := test.deleteClusterRole(cr.Name); err != nil {
+		if err := root.deleteClusterRole(cr.Name); err != nil {
 			return err
 		}
 		return nil
@@ -53,29 +59,18 @@ func (test *Test) LoadClusterRole(manifestPath string) *rbacv1.ClusterRole {
 	return cr
 }
 
-func (test *Test) createClusterRoleFromFile(manifestPath string) (*rbacv1.ClusterRole, error) {
-	cr, err := test.loadClusterRole(manifestPath)
-	if err != nil {
-		return nil, err
-	}
-	err = test.createClusterRole(cr)
-	if err != nil {
-		return nil, err
-	}
-	return cr, nil
-}
-
-// CreateClusterRoleFromFile creates a cluster role from a manifest file.
+// CreateClusterRoleFromFile creates a cluster role from a manifest file. The
+// cluster role is deleted when the test is closed.
 func (test *Test) CreateClusterRoleFromFile(manifestPath string) *rbacv1.ClusterRole {
-	cr, err := test.createClusterRoleFromFile(manifestPath)
-	test.err(err)
+	cr := test.LoadClusterRole(manifestPath)
+	test.CreateClusterRole(cr)
 	return cr
 }
 
 func (test *Test) deleteClusterRole(name string) error {
 	test.Debugf("deleting cluster role %s", name)
 
-	if err := test.harness.kubeClient.RbacV1().ClusterRoles().Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+	if err := test.kubeClient.RbacV1().ClusterRoles().Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
 		return fmt.Errorf("deleting cluster role %s failed: %w", name, err)
 	}
 	return nil
@@ -89,7 +84,7 @@ func (test *Test) DeleteClusterRole(cr *rbacv1.ClusterRole) {
 
 // GetClusterRole returns a ClusterRole object if it exists or error.
 func (test *Test) GetClusterRole(name string) (*rbacv1.ClusterRole, error) {
-	cr, err := test.harness.kubeClient.RbacV1().ClusterRoles().Get(context.TODO(), name, metav1.GetOptions{})
+	cr, err := test.kubeClient.RbacV1().ClusterRoles().Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -99,8 +94,11 @@ func (test *Test) GetClusterRole(name string) (*rbacv1.ClusterRole, error) {
 func (test *Test) waitForClusterRoleReady(name string, timeout time.Duration) error {
 	test.Debugf("waiting for cluster role %s to be ready", name)
 
-	return wait.Poll(time.Second, timeout, func() (bool, error) {
+	return wait.Poll(rbacPollInterval, timeout, func() (bool, error) {
 		_, err := test.GetClusterRole(name)
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
 		if err != nil {
 			return false, err
 		}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/util/yaml"
@@ -14,19 +15,23 @@ import (
 func (test *Test) createClusterRoleBinding(crb *rbacv1.ClusterRoleBinding) error {
 	test.Debugf("creating cluster role binding %s", crb.Name)
 
-	if _, err := test.harness.kubeClient.RbacV1().ClusterRoleBindings().Create(context.TODO(), crb, metav1.CreateOptions{}); err != nil {
+	if _, err := test.kubeClient.RbacV1().ClusterRoleBindings().Create(context.TODO(), crb, metav1.CreateOptions{}); err != nil {
 		return fmt.Errorf("failed to create cluster role binding %s: %w", crb.Name, err)
 	}
 	return nil
 }
 
-// CreateClusterRoleBinding creates a cluster role binding.
+// CreateClusterRoleBinding creates a cluster role binding. As for
+// CreateClusterRole, the binding is deleted when the test is closed.
 func (test *Test) CreateClusterRoleBinding(crb *rbacv1.ClusterRoleBinding) {
-	err := test.createClusterRoleBinding(crb)
-	test.err(err)
+	if err := test.createClusterRoleBinding(crb); err != nil {
+		test.err(err)
+		return
+	}
 
+	root := test.root()
 	test.addFinalizer(func() error {
-		if err := test.deleteClusterRoleBinding(crb.Name); err != nil {
+		if err := root.deleteClusterRoleBinding(crb.Name); err != nil {
 			return err
 		}
 		return nil
@@ -53,29 +58,18 @@ func (test *Test) LoadClusterRoleBinding(manifestPath string) *rbacv1.ClusterRol
 	return crb
 }
 
-func (test *Test) createClusterRoleBindingFromFile(manifestPath string) (*rbacv1.ClusterRoleBinding, error) {
-	crb, err := test.loadClusterRoleBinding(manifestPath)
-	if err != nil {
-		return nil, err
-	}
-	err = test.createClusterRoleBinding(crb)
-	if err != nil {
-		return nil, err
-	}
-	return crb, nil
-}
-
-// CreateClusterRoleBindingFromFile creates a cluster role binding from a manifest file.
+// CreateClusterRoleBindingFromFile creates a cluster role binding from a
+// manifest file. The cluster role binding is deleted when the test is closed.
 func (test *Test) CreateClusterRoleBindingFromFile(manifestPath string) *rbacv1.ClusterRoleBinding {
-	crb, err := test.createClusterRoleBindingFromFile(manifestPath)
-	test.err(err)
+	crb := test.LoadClusterRoleBinding(manifestPath)
+	test.CreateClusterRoleBinding(crb)
 	return crb
 }
 
 func (test *Test) deleteClusterRoleBinding(name string) error {
 	test.Debugf("deleting cluster role binding %s", name)
 
-	if err := test.harness.kubeClient.RbacV1().ClusterRoleBindings().Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+	if err := test.kubeClient.RbacV1().ClusterRoleBindings().Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
 		return fmt.Errorf("deleting cluster role binding %s failed: %w", name, err)
 	}
 	return nil
@@ -89,7 +83,7 @@ func (test *Test) DeleteClusterRoleBinding(crb *rbacv1.ClusterRoleBinding) {
 
 // GetClusterRoleBinding returns a ClusterRoleBinding object if it exists or error.
 func (test *Test) GetClusterRoleBinding(name string) (*rbacv1.ClusterRoleBinding, error) {
-	crb, err := test.harness.kubeClient.RbacV1().ClusterRoleBindings().Get(context.TODO(), name, metav1.GetOptions{})
+	crb, err := test.kubeClient.RbacV1().ClusterRoleBindings().Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -99,8 +93,11 @@ func (test *Test) GetClusterRoleBinding(name string) (*rbacv1.ClusterRoleBinding
 func (test *Test) waitForClusterRoleBindingReady(name string, timeout time.Duration) error {
 	test.Debugf("waiting for cluster role binding %s to be ready", name)
 
-	return wait.Poll(time.Second, timeout, func() (bool, error) {
+	return wait.Poll(rbacPollInterval, timeout, func() (bool, error) {
 		_, err := test.GetClusterRoleBinding(name)
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
 		if err != nil {
 			return false, err
 		}
@@ -109,7 +106,7 @@ func (test *Test) waitForClusterRoleBindingReady(name string, timeout time.Durat
 }
 
 // WaitForClusterRoleBindingReady waits until ClusterRoleBinding is created, otherwise times out.
-func (test *Test) WaitForClusterRoleBindingReady(crb *rbacv1.ClusterRole, timeout time.Duration) {
+func (test *Test) WaitForClusterRoleBindingReady(crb *rbacv1.ClusterRoleBinding, timeout time.Duration) {
 	err := test.waitForClusterRoleBindingReady(crb.Name, timeout)
 	test.err(err)
 }
@@ -15,7 +15,7 @@ func (test *Test) createConfigMap(namespace string, cm *v1.ConfigMap) error {
 	test.Debugf("creating configmap %s", cm.Name)
 
 	cm.Namespace = namespace
-	if _, err := test.harness.kubeClient.CoreV1().ConfigMaps(namespace).Create(context.TODO(), cm, metav1.CreateOptions{}); err != nil {
+	if _, err := test.kubeClient.CoreV1().ConfigMaps(namespace).Create(context.TODO(), cm, metav1.CreateOptions{}); err != nil {
 		return fmt.Errorf("failed to create ConfigMap %s: %w", cm.Name, err)
 	}
 	return nil
@@ -70,7 +70,7 @@ func (test *Test) CreateConfigMapFromFile(namespace string, manifestPath string)
 func (test *Test) deleteConfigMap(cm *v1.ConfigMap) error {
 	test.Debugf("deleting configmap %s", cm.Name)
 
-	if err := test.harness.kubeClient.CoreV1().ConfigMaps(cm.Namespace).Delete(context.TODO(), cm.Name, metav1.DeleteOptions{}); err != nil {
+	if err := test.kubeClient.CoreV1().ConfigMaps(cm.Namespace).Delete(context.TODO(), cm.Name, metav1.DeleteOptions{}); err != nil {
 		return fmt.Errorf("deleting ConfigMap %s failed: %w", cm.Name, err)
 	}
 	return nil
@@ -84,7 +84,7 @@ func (test *Test) DeleteConfigMap(ConfigMap *v1.ConfigMap) {
 
 // GetConfigMap returns a ConfigMap object if it exists or error.
 func (test *Test) GetConfigMap(ns, name string) (*v1.ConfigMap, error) {
-	cm, err := test.harness.kubeClient.CoreV1().ConfigMaps(ns).Get(context.TODO(), name, metav1.GetOptions{})
+	cm, err := test.kubeClient.CoreV1().ConfigMaps(ns).Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,329 @@
+package harness
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// ProbeImage is the container image used by the helper pods the harness
+// creates, eg. probe pods. The image needs a shell as well as the nc and wget
+// binaries.
+var ProbeImage = "busybox:1.32"
+
+// ProbeTimeout is how long a connectivity probe waits for a connection to be
+// established. It's rounded up to the second.
+var ProbeTimeout = 2 * time.Second
+
+// maxConcurrentProbes limits the number of exec sessions used to probe
+// connectivity at the same time.
+const maxConcurrentProbes = 10
+
+// ProbeProtocol is how connectivity is checked.
+type ProbeProtocol string
+
+const (
+	// ProbeTCP checks a TCP connection can be established.
+	ProbeTCP ProbeProtocol = "tcp"
+	// ProbeHTTP checks an HTTP GET on / gets a response, whatever its status.
+	ProbeHTTP ProbeProtocol = "http"
+)
+
+// ProbePort is a port to probe.
+type ProbePort struct {
+	Port     int
+	Protocol ProbeProtocol
+}
+
+func (p ProbePort) String() string {
+	return fmt.Sprintf("%s/%d", p.Protocol, p.Port)
+}
+
+type connectivityKey struct {
+	from, to string
+	port     ProbePort
+}
+
+func podKey(pod *v1.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
+// ConnectivityMatrix records, for each port, which pods can reach which. It's
+// both the result of Test.ConnectivityMatrix and the expected table given to
+// Test.AssertConnectivity.
+type ConnectivityMatrix struct {
+	From  []*v1.Pod
+	To    []*v1.Pod
+	Ports []ProbePort
+
+	mu        sync.Mutex
+	reachable map[connectivityKey]bool
+}
+
+// NewConnectivityMatrix creates a matrix where no pod can reach any other pod.
+func NewConnectivityMatrix(from, to []*v1.Pod, ports []ProbePort) *ConnectivityMatrix {
+	return &ConnectivityMatrix{
+		From:      from,
+		To:        to,
+		Ports:     ports,
+		reachable: make(map[connectivityKey]bool),
+	}
+}
+
+// Set records whether from can reach to on port.
+func (m *ConnectivityMatrix) Set(from, to *v1.Pod, port ProbePort, reachable bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reachable[connectivityKey{podKey(from), podKey(to), port}] = reachable
+}
+
+// SetAll sets the reachability of all the pairs of pods on all ports.
+func (m *ConnectivityMatrix) SetAll(reachable bool) {
+	for _, from := range m.From {
+		for _, to := range m.To {
+			for _, port := range m.Ports {
+				m.Set(from, to, port, reachable)
+			}
+		}
+	}
+}
+
+// Reachable returns whether from can reach to on port.
+func (m *ConnectivityMatrix) Reachable(from, to *v1.Pod, port ProbePort) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reachable[connectivityKey{podKey(from), podKey(to), port}]
+}
+
+func reachableString(reachable bool) string {
+	if reachable {
+		return "yes"
+	}
+	return "no"
+}
+
+// String returns the matrix as a table with a row per source pod and a column
+// per destination pod and port.
+func (m *ConnectivityMatrix) String() string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 1, ' ', 0)
+
+	fmt.Fprint(tw, "FROM\\TO")
+	for _, to := range m.To {
+		for _, port := range m.Ports {
+			fmt.Fprintf(tw, "\t  %s:%s", podKey(to), port)
+		}
+	}
+	fmt.Fprintln(tw)
+
+	for _, from := range m.From {
+		fmt.Fprint(tw, podKey(from))
+		for _, to := range m.To {
+			for _, port := range m.Ports {
+				fmt.Fprintf(tw, "\t  %s", reachableString(m.Reachable(from, to, port)))
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+
+	tw.Flush()
+	return buf.String()
+}
+
+// Diff compares m with expected on the pods and ports of expected. It returns
+// a table of the mismatches or "" when m matches expected.
+func (m *ConnectivityMatrix) Diff(expected *ConnectivityMatrix) string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 1, ' ', 0)
+
+	fmt.Fprintln(tw, "FROM\t  TO\t  PORT\t  EXPECTED\t  ACTUAL")
+	mismatches := 0
+	for _, from := range expected.From {
+		for _, to := range expected.To {
+			for _, port := range expected.Ports {
+				want := expected.Reachable(from, to, port)
+				got := m.Reachable(from, to, port)
+				if want == got {
+					continue
+				}
+				mismatches++
+				fmt.Fprintf(tw, "%s\t  %s\t  %s\t  %s\t  %s\n",
+					podKey(from), podKey(to), port,
+					reachableString(want), reachableString(got))
+			}
+		}
+	}
+	tw.Flush()
+
+	if mismatches == 0 {
+		return ""
+	}
+	return buf.String()
+}
+
+// probeTimeout returns ProbeTimeout in seconds, as taken by nc and wget,
+// rounded up to at least a second.
+func probeTimeout() string {
+	seconds := int((ProbeTimeout + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
+
+// probeCommand returns the command probing port of to. The HTTP probe prints
+// the response headers on stderr with -S: wget exits with an error on a 4xx or
+// 5xx response, but the server is still reachable.
+func probeCommand(to *v1.Pod, port ProbePort) ([]string, error) {
+	timeout := probeTimeout()
+	address := net.JoinHostPort(to.Status.PodIP, strconv.Itoa(port.Port))
+
+	switch port.Protocol {
+	case ProbeTCP:
+		return []string{"nc", "-z", "-w", timeout, to.Status.PodIP, strconv.Itoa(port.Port)}, nil
+	case ProbeHTTP:
+		return []string{"wget", "-S", "-T", timeout, "-O", "/dev/null", "http://" + address + "/"}, nil
+	default:
+		return nil, fmt.Errorf("unknown probe protocol %q", port.Protocol)
+	}
+}
+
+// httpResponse matches the status line of an HTTP response printed by wget -S.
+var httpResponse = regexp.MustCompile(`(?m)^\s*HTTP/\d`)
+
+// probe returns whether from can reach to on port.
+func (test *Test) probe(from, to *v1.Pod, port ProbePort) (bool, error) {
+	if to.Status.PodIP == "" {
+		return false, fmt.Errorf("probe: pod %s has no IP address", to.Name)
+	}
+	command, err := probeCommand(to, port)
+	if err != nil {
+		return false, err
+	}
+
+	// The API server refuses exec requests without any stream.
+	var stderr bytes.Buffer
+	err = test.podExec(from, "", command, nil, ioutil.Discard, &stderr)
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		switch {
+		case exitErr.ExitStatus() == 126 || exitErr.ExitStatus() == 127:
+			// The shell couldn't run the probe command: it's missing or isn't
+			// executable in the container image.
+			return false, fmt.Errorf("probe from %s to %s:%s: can't run %s: %w: %s", from.Name, to.Name, port, command[0], err, stderr.String())
+		case port.Protocol == ProbeHTTP && httpResponse.Match(stderr.Bytes()):
+			return true, nil
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("probe from %s to %s:%s failed: %w: %s", from.Name, to.Name, port, err, stderr.String())
+	}
+	return true, nil
+}
+
+func (test *Test) connectivityMatrix(from, to []*v1.Pod, ports []ProbePort) (*ConnectivityMatrix, error) {
+	test.Debugf("probing connectivity between %d and %d pods", len(from), len(to))
+
+	if err := test.needsControllers("ConnectivityMatrix"); err != nil {
+		return nil, err
+	}
+
+	m := NewConnectivityMatrix(from, to, ports)
+	sem := make(chan struct{}, maxConcurrentProbes)
+	var eg errgroup.Group
+
+	for _, f := range from {
+		for _, t := range to {
+			for _, port := range ports {
+				f, t, port := f, t, port
+				eg.Go(func() error {
+					sem <- struct{}{}
+					defer func() { <-sem }()
+
+					reachable, err := test.probe(f, t, port)
+					if err != nil {
+						return err
+					}
+					m.Set(f, t, port, reachable)
+					return nil
+				})
+			}
+		}
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ConnectivityMatrix checks, for each port, which pods of from can reach which
+// pods of to. Connections are attempted by running nc or wget in the from pods,
+// which must have a single container with those binaries. Probe pods created
+// with CreateProbePod can be used as sources. The to pods are contacted on
+// their IP address.
+func (test *Test) ConnectivityMatrix(from, to []*v1.Pod, ports []ProbePort) *ConnectivityMatrix {
+	m, err := test.connectivityMatrix(from, to, ports)
+	test.err(err)
+	return m
+}
+
+// AssertConnectivity probes the connectivity between the pods of expected and
+// fails the test with a table of the mismatches if the result differs from
+// expected.
+func (test *Test) AssertConnectivity(expected *ConnectivityMatrix) {
+	m, err := test.connectivityMatrix(expected.From, expected.To, expected.Ports)
+	if err != nil {
+		test.err(err)
+		return
+	}
+
+	if diff := m.Diff(expected); diff != "" {
+		test.err(fmt.Errorf("unexpected connectivity:\n%s", diff))
+	}
+}
+
+func (test *Test) createProbePod(namespace string, labels map[string]string) (*v1.Pod, error) {
+	var zero int64
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   test.getObjID("probe"),
+			Labels: labels,
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name:    "probe",
+				Image:   ProbeImage,
+				Command: []string{"sleep", "86400"},
+			}},
+			TerminationGracePeriodSeconds: &zero,
+		},
+	}
+	if err := test.createPod(namespace, pod); err != nil {
+		return nil, err
+	}
+	return test.waitForPodReady(pod, 2*time.Minute)
+}
+
+// CreateProbePod creates a lightweight pod that can be used as a source by
+// ConnectivityMatrix and waits for it to be ready. The pod is given labels so
+// network policies can select it. Probe pods are deleted with the test
+// namespace.
+func (test *Test) CreateProbePod(namespace string, labels map[string]string) *v1.Pod {
+	pod, err := test.createProbePod(namespace, labels)
+	test.err(err)
+	return pod
+}
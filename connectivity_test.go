@@ -0,0 +1,183 @@
+package harness
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlespiau/kube-test-harness/logger"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+func newPod(name string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+}
+
+func TestConnectivityMatrixDiff(t *testing.T) {
+	a, b, c := newPod("a"), newPod("b"), newPod("c")
+	http := ProbePort{80, ProbeHTTP}
+	ports := []ProbePort{http}
+
+	expected := NewConnectivityMatrix([]*v1.Pod{a}, []*v1.Pod{b, c}, ports)
+	expected.SetAll(true)
+	expected.Set(a, c, http, false)
+
+	actual := NewConnectivityMatrix([]*v1.Pod{a}, []*v1.Pod{b, c}, ports)
+	actual.Set(a, b, http, true)
+	assert.Equal(t, "", actual.Diff(expected))
+	assert.True(t, actual.Reachable(a, b, http))
+	assert.False(t, actual.Reachable(a, c, http))
+
+	actual.Set(a, b, http, false)
+	actual.Set(a, c, http, true)
+	diff := actual.Diff(expected)
+	lines := strings.Split(strings.TrimSpace(diff), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, []string{"ns/a", "ns/b", "http/80", "yes", "no"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"ns/a", "ns/c", "http/80", "no", "yes"}, strings.Fields(lines[2]))
+}
+
+func TestConnectivityMatrixString(t *testing.T) {
+	a, b := newPod("a"), newPod("b")
+	tcp := ProbePort{5432, ProbeTCP}
+
+	m := NewConnectivityMatrix([]*v1.Pod{a, b}, []*v1.Pod{b}, []ProbePort{tcp})
+	m.Set(a, b, tcp, true)
+
+	lines := strings.Split(strings.TrimSpace(m.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, []string{"FROM\\TO", "ns/b:tcp/5432"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"ns/a", "yes"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"ns/b", "no"}, strings.Fields(lines[2]))
+}
+
+// fakeExecutor runs stream instead of executing commands in containers.
+type fakeExecutor struct {
+	stream func(remotecommand.StreamOptions) error
+}
+
+func (e *fakeExecutor) Stream(opts remotecommand.StreamOptions) error {
+	return e.stream(opts)
+}
+
+func TestProbe(t *testing.T) {
+	config := &rest.Config{Host: "https://127.0.0.1:6443"}
+	client, err := kubernetes.NewForConfig(config)
+	assert.NoError(t, err)
+	test := &Test{
+		kubeClient: client,
+		restConfig: config,
+		t:          t,
+		logger:     (&logger.TestLogger{}).ForTest(t),
+	}
+
+	from, to := newPod("client"), newPod("server")
+	from.Spec.Containers = []v1.Container{{Name: "probe"}}
+	to.Status.PodIP = "10.0.0.2"
+
+	tests := []struct {
+		name      string
+		protocol  ProbeProtocol
+		err       error
+		stderr    string
+		reachable bool
+		wantErr   string
+	}{
+		{"reachable", ProbeTCP, nil, "", true, ""},
+		{"unreachable", ProbeTCP, utilexec.CodeExitError{Err: errors.New("exit 1"), Code: 1}, "", false, ""},
+		{"exec failure", ProbeTCP, errors.New("container not found"), "nc: not found", false, "container not found: nc: not found"},
+		{"command not found", ProbeTCP, utilexec.CodeExitError{Err: errors.New("exit 127"), Code: 127}, "sh: nc: not found", false, "can't run nc"},
+		{"command not executable", ProbeTCP, utilexec.CodeExitError{Err: errors.New("exit 126"), Code: 126}, "", false, "can't run nc"},
+		{"http reachable", ProbeHTTP, nil, "  HTTP/1.1 200 OK\n", true, ""},
+		{"http error response", ProbeHTTP, utilexec.CodeExitError{Err: errors.New("exit 1"), Code: 1}, "  HTTP/1.1 404 Not Found\nwget: server returned error: HTTP/1.1 404 Not Found\n", true, ""},
+		{"http unreachable", ProbeHTTP, utilexec.CodeExitError{Err: errors.New("exit 1"), Code: 1}, "wget: download timed out\n", false, ""},
+	}
+
+	defer func(saved func(*rest.Config, string, *url.URL) (remotecommand.Executor, error)) { newExecutor = saved }(newExecutor)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newExecutor = func(_ *rest.Config, method string, u *url.URL) (remotecommand.Executor, error) {
+				assert.Equal(t, "POST", method)
+				command := map[ProbeProtocol]string{ProbeTCP: "nc", ProbeHTTP: "wget"}[tt.protocol]
+				assert.Contains(t, u.RawQuery, "command="+command)
+				return &fakeExecutor{func(opts remotecommand.StreamOptions) error {
+					// The API server needs at least one stream.
+					assert.NotNil(t, opts.Stdout)
+					assert.NotNil(t, opts.Stderr)
+					io.WriteString(opts.Stderr, tt.stderr)
+					return tt.err
+				}}, nil
+			}
+
+			reachable, err := test.probe(from, to, ProbePort{Protocol: tt.protocol, Port: 80})
+			assert.Equal(t, tt.reachable, reachable)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	defer func(saved time.Duration) { ProbeTimeout = saved }(ProbeTimeout)
+
+	tests := []struct {
+		timeout time.Duration
+		want    string
+	}{
+		{0, "1"},
+		{500 * time.Millisecond, "1"},
+		{time.Second, "1"},
+		{1500 * time.Millisecond, "2"},
+		{3 * time.Second, "3"},
+	}
+	for _, tt := range tests {
+		ProbeTimeout = tt.timeout
+		assert.Equal(t, tt.want, probeTimeout(), tt.timeout.String())
+	}
+}
+
+func TestAssertConnectivityForbidden(t *testing.T) {
+	config := &rest.Config{Host: "https://127.0.0.1:6443"}
+	client, err := kubernetes.NewForConfig(config)
+	assert.NoError(t, err)
+	alice := &Test{
+		kubeClient: client,
+		restConfig: config,
+		t:          t,
+		logger:     (&logger.TestLogger{}).ForTest(t),
+		user:       "alice",
+	}
+
+	from, to := newPod("client"), newPod("server")
+	from.Spec.Containers = []v1.Container{{Name: "probe"}}
+	to.Status.PodIP = "10.0.0.2"
+
+	defer func(saved func(*rest.Config, string, *url.URL) (remotecommand.Executor, error)) { newExecutor = saved }(newExecutor)
+	newExecutor = func(*rest.Config, string, *url.URL) (remotecommand.Executor, error) {
+		return &fakeExecutor{func(remotecommand.StreamOptions) error {
+			return apierrors.NewForbidden(schema.GroupResource{Resource: "pods/exec"}, "client", errors.New("rbac"))
+		}}, nil
+	}
+
+	expected := NewConnectivityMatrix([]*v1.Pod{from}, []*v1.Pod{to}, []ProbePort{{Protocol: ProbeTCP, Port: 80}})
+	denied := alice.Forbidden(func() {
+		alice.AssertConnectivity(expected)
+	})
+	assert.NotNil(t, denied)
+	assert.Equal(t, "alice", denied.User)
+}
@@ -0,0 +1,44 @@
+package harness
+
+import "fmt"
+
+// controlPlaneProvider is implemented by cluster providers creating clusters
+// made of a control plane only, eg. provider.ControlPlane.
+type controlPlaneProvider interface {
+	ControlPlaneOnly() bool
+}
+
+// NoControllersError is returned by helpers waiting for the cluster to
+// reconcile objects, eg. for a Deployment to be ready, when the cluster only
+// has a control plane. See Options.ControlPlaneOnly and
+// ClusterOptions.ControlPlaneOnly.
+type NoControllersError struct {
+	// Helper is the name of the helper that can't be used.
+	Helper string
+}
+
+func (e *NoControllersError) Error() string {
+	return fmt.Sprintf("%s needs controllers and nodes but the cluster only runs an API server and etcd: objects are stored but never reconciled, pods never run", e.Helper)
+}
+
+// controlPlaneOnly returns whether the cluster targeted by test only runs a
+// control plane.
+func (test *Test) controlPlaneOnly() bool {
+	if test.harness == nil {
+		return false
+	}
+	if test.clusterName != "" {
+		c, ok := test.harness.clusters[test.clusterName]
+		return ok && c.controlPlaneOnly
+	}
+	return test.harness.options.ControlPlaneOnly
+}
+
+// needsControllers returns a NoControllersError when the cluster doesn't run
+// the controllers helper relies on.
+func (test *Test) needsControllers(helper string) error {
+	if !test.controlPlaneOnly() {
+		return nil
+	}
+	return &NoControllersError{helper}
+}
@@ -0,0 +1,85 @@
+package harness
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dlespiau/kube-test-harness/logger"
+	"github.com/dlespiau/kube-test-harness/provider"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// controlPlaneFake is a fake provider creating control plane only clusters.
+type controlPlaneFake struct {
+	ClusterProvider
+}
+
+func (controlPlaneFake) ControlPlaneOnly() bool {
+	return true
+}
+
+func TestNeedsControllers(t *testing.T) {
+	test := &Test{
+		harness:    &Harness{options: Options{ControlPlaneOnly: true}},
+		kubeClient: fake.NewSimpleClientset(),
+		t:          t,
+		logger:     (&logger.TestLogger{}).ForTest(t),
+	}
+
+	d := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app"}}
+	err := test.waitForDeploymentReady(d, time.Second)
+	var noControllers *NoControllersError
+	assert.True(t, errors.As(err, &noControllers))
+	assert.Equal(t, "WaitForDeploymentReady", noControllers.Helper)
+
+	err = test.podExec(&v1.Pod{}, "", []string{"true"}, nil, nil, nil)
+	assert.True(t, errors.As(err, &noControllers))
+	assert.Equal(t, "PodExec", noControllers.Helper)
+
+	test.harness.options.ControlPlaneOnly = false
+	assert.NoError(t, test.needsControllers("WaitForDeploymentReady"))
+}
+
+func TestNeedsControllersPerCluster(t *testing.T) {
+	h := &Harness{
+		options: Options{ControlPlaneOnly: true},
+		clusters: map[string]*cluster{
+			"real":  {restConfig: &rest.Config{Host: "https://real:6443"}},
+			"local": {restConfig: &rest.Config{Host: "https://local:6443"}, controlPlaneOnly: true},
+		},
+	}
+	test := &Test{
+		harness: h,
+		t:       t,
+		logger:  (&logger.TestLogger{}).ForTest(t),
+	}
+
+	assert.Error(t, test.needsControllers("WaitForDeploymentReady"))
+	real, err := test.cluster("real")
+	assert.NoError(t, err)
+	assert.NoError(t, real.needsControllers("WaitForDeploymentReady"))
+	local, err := test.cluster("local")
+	assert.NoError(t, err)
+	assert.Error(t, local.needsControllers("WaitForDeploymentReady"))
+
+	// Views target the cluster of the test they have been created from.
+	alice, err := real.as("alice", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, alice.needsControllers("WaitForDeploymentReady"))
+}
+
+func TestControlPlaneProvider(t *testing.T) {
+	h := New(Options{
+		ClusterProvider: controlPlaneFake{&provider.Fake{}},
+		Logger:          &logger.PrintfLogger{},
+	})
+
+	assert.NoError(t, h.createCluster())
+	assert.True(t, h.options.ControlPlaneOnly)
+}
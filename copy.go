@@ -0,0 +1,227 @@
+package harness
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// writeTar writes a tar archive of src, a file or a directory, on w. The
+// archive entries are rooted at name instead of the base name of src.
+func writeTar(w io.Writer, src, name string) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.Walk(src, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(name, filepath.ToSlash(rel))
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// readTar extracts the tar archive read from r into dst. Only the entries
+// rooted at name are extracted, name itself being renamed to dst.
+func readTar(r io.Reader, name, dst string) error {
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// The entry isn't cleaned before being filtered: a name such as
+		// name/../../file must be caught by the check below.
+		entry := strings.TrimSuffix(hdr.Name, "/")
+		if entry != name && !strings.HasPrefix(entry, name+"/") {
+			continue
+		}
+		target := filepath.Join(dst, filepath.FromSlash(strings.TrimPrefix(entry, name)))
+		if rel, err := filepath.Rel(dst, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("tar entry %s points outside of the destination directory", hdr.Name)
+		}
+
+		mode := hdr.FileInfo().Mode()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode.Perm()); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		default:
+			// Symbolic links and special files are not extracted, they could be
+			// used to write outside of dst.
+			continue
+		}
+
+		// Don't let the umask decide of the final permissions.
+		if err := os.Chmod(target, mode.Perm()); err != nil {
+			return err
+		}
+	}
+}
+
+func (test *Test) copyToPod(pod *v1.Pod, containerName, src, dst string) error {
+	test.Debugf("copying %s to pod %s:%s", src, pod.Name, dst)
+
+	if err := test.needsControllers("CopyToPod"); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("copy to pod: %w", err)
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(writeTar(w, src, path.Base(dst)))
+	}()
+	defer r.Close()
+
+	var stderr strings.Builder
+	command := []string{"tar", "-xmf", "-", "-C", path.Dir(dst)}
+	if err := test.podExec(pod, containerName, command, r, nil, &stderr); err != nil {
+		return fmt.Errorf("failed to copy %s to pod %s: %w: %s", src, pod.Name, err, stderr.String())
+	}
+	return nil
+}
+
+// CopyToPod copies src, a local file or directory, to the dst path in a
+// container of pod. File permissions are preserved. If the pod has a single
+// container, containerName is optional and can be set to "".
+//
+// Similarly to kubectl cp, the container image needs to include the tar
+// binary.
+func (test *Test) CopyToPod(pod *v1.Pod, containerName, src, dst string) {
+	test.err(test.copyToPod(pod, containerName, src, dst))
+}
+
+func (test *Test) copyFromPod(pod *v1.Pod, containerName, src, dst string) error {
+	test.Debugf("copying pod %s:%s to %s", pod.Name, src, dst)
+
+	if err := test.needsControllers("CopyFromPod"); err != nil {
+		return err
+	}
+
+	src = path.Clean(src)
+	r, w := io.Pipe()
+	var stderr strings.Builder
+	done := make(chan error, 1)
+	go func() {
+		command := []string{"tar", "-cf", "-", "-C", path.Dir(src), path.Base(src)}
+		err := test.podExec(pod, containerName, command, nil, w, &stderr)
+		w.CloseWithError(err)
+		done <- err
+	}()
+
+	err := readTar(r, path.Base(src), dst)
+	if err == nil {
+		// Consume the end of archive padding.
+		_, err = io.Copy(ioutil.Discard, r)
+	}
+	// Unblock the exec goroutine if we stopped reading early.
+	r.CloseWithError(err)
+	if execErr := <-done; err == nil {
+		err = execErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy %s from pod %s: %w: %s", src, pod.Name, err, stderr.String())
+	}
+	return nil
+}
+
+// CopyFromPod copies src, a file or directory in a container of pod, to the
+// local dst path. File permissions are preserved. If the pod has a single
+// container, containerName is optional and can be set to "".
+//
+// Similarly to kubectl cp, the container image needs to include the tar
+// binary.
+func (test *Test) CopyFromPod(pod *v1.Pod, containerName, src, dst string) {
+	test.err(test.copyFromPod(pod, containerName, src, dst))
+}
+
+// ArtifactDirectory returns the directory where the test saves its artifacts
+// or "" if Options.ArtifactDirectory hasn't been set.
+func (test *Test) ArtifactDirectory() string {
+	if test.harness.options.ArtifactDirectory == "" {
+		return ""
+	}
+	return filepath.Join(test.harness.options.ArtifactDirectory, test.ID)
+}
+
+// SaveArtifactFromPod copies src, a file or directory in a container of pod,
+// to the test artifact directory, under a sub-directory named after the pod.
+// It does nothing if Options.ArtifactDirectory hasn't been set.
+func (test *Test) SaveArtifactFromPod(pod *v1.Pod, containerName, src string) {
+	dir := test.ArtifactDirectory()
+	if dir == "" {
+		test.Debugf("no artifact directory, not saving %s from pod %s", src, pod.Name)
+		return
+	}
+
+	dst := filepath.Join(dir, pod.Name, path.Base(path.Clean(src)))
+	test.err(test.copyFromPod(pod, containerName, src, dst))
+}
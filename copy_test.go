@@ -0,0 +1,85 @@
+package harness
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTarRoundTrip(t *testing.T) {
+	src, err := ioutil.TempDir("", "kth-copy-src")
+	assert.NoError(t, err)
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "kth-copy-dst")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dst)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, "fixtures", "sub"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "fixtures", "data.json"), []byte("{}"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "fixtures", "sub", "run.sh"), []byte("#!/bin/sh"), 0755))
+
+	var buf bytes.Buffer
+	assert.NoError(t, writeTar(&buf, filepath.Join(src, "fixtures"), "seed"))
+	assert.NoError(t, readTar(&buf, "seed", filepath.Join(dst, "out")))
+
+	data, err := ioutil.ReadFile(filepath.Join(dst, "out", "data.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+
+	fi, err := os.Stat(filepath.Join(dst, "out", "data.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	fi, err = os.Stat(filepath.Join(dst, "out", "sub", "run.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+}
+
+func TestReadTarOutsideDestination(t *testing.T) {
+	dst, err := ioutil.TempDir("", "kth-copy-dst")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dst)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "seed/../../escape",
+		Mode:     0644,
+		Typeflag: tar.TypeReg,
+	}))
+	assert.NoError(t, tw.Close())
+
+	err = readTar(&buf, "seed", filepath.Join(dst, "out"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "outside of the destination directory")
+	_, err = os.Stat(filepath.Join(dst, "escape"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestReadTarDotDotPrefix(t *testing.T) {
+	dst, err := ioutil.TempDir("", "kth-copy-dst")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dst)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "seed/..foo",
+		Mode:     0644,
+		Size:     2,
+		Typeflag: tar.TypeReg,
+	}))
+	_, err = tw.Write([]byte("{}"))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+
+	assert.NoError(t, readTar(&buf, "seed", filepath.Join(dst, "out")))
+	data, err := ioutil.ReadFile(filepath.Join(dst, "out", "..foo"))
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+}
@@ -0,0 +1,152 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func (test *Test) createCronJob(namespace string, cj *batchv1beta1.CronJob) error {
+	test.Debugf("creating cronjob %s", cj.Name)
+
+	cj.Namespace = namespace
+	if _, err := test.kubeClient.BatchV1beta1().CronJobs(namespace).Create(context.TODO(), cj, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create cronjob %s: %w", cj.Name, err)
+	}
+	return nil
+}
+
+// CreateCronJob creates a cronjob in the given namespace.
+func (test *Test) CreateCronJob(namespace string, cj *batchv1beta1.CronJob) {
+	err := test.createCronJob(namespace, cj)
+	test.err(err)
+}
+
+func (test *Test) loadCronJob(manifestPath string) (*batchv1beta1.CronJob, error) {
+	manifest, err := test.harness.openManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	cj := batchv1beta1.CronJob{}
+	if err := yaml.NewYAMLOrJSONDecoder(manifest, 100).Decode(&cj); err != nil {
+		return nil, fmt.Errorf("failed to decode cronjob %s: %w", manifestPath, err)
+	}
+
+	return &cj, nil
+}
+
+// LoadCronJob loads a cronjob from a YAML manifest. The path to the manifest
+// is relative to Harness.ManifestDirectory.
+func (test *Test) LoadCronJob(manifestPath string) *batchv1beta1.CronJob {
+	cj, err := test.loadCronJob(manifestPath)
+	test.err(err)
+	return cj
+}
+
+func (test *Test) createCronJobFromFile(namespace string, manifestPath string) (*batchv1beta1.CronJob, error) {
+	cj, err := test.loadCronJob(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	err = test.createCronJob(namespace, cj)
+	if err != nil {
+		return nil, err
+	}
+	return cj, nil
+}
+
+// CreateCronJobFromFile creates a cronjob from a manifest file in the given namespace.
+func (test *Test) CreateCronJobFromFile(namespace string, manifestPath string) *batchv1beta1.CronJob {
+	cj, err := test.createCronJobFromFile(namespace, manifestPath)
+	test.err(err)
+	return cj
+}
+
+// GetCronJob returns a CronJob object if it exists or error.
+func (test *Test) GetCronJob(ns, name string) (*batchv1beta1.CronJob, error) {
+	cj, err := test.kubeClient.BatchV1beta1().CronJobs(ns).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return cj, nil
+}
+
+func (test *Test) deleteCronJob(cj *batchv1beta1.CronJob) error {
+	test.Debugf("deleting cronjob %s", cj.Name)
+
+	propagation := metav1.DeletePropagationBackground
+	if err := test.kubeClient.BatchV1beta1().CronJobs(cj.Namespace).Delete(context.TODO(), cj.Name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	}); err != nil {
+		return fmt.Errorf("deleting cronjob %s failed: %w", cj.Name, err)
+	}
+	return nil
+}
+
+// DeleteCronJob deletes a cronjob and the jobs it has created.
+func (test *Test) DeleteCronJob(cj *batchv1beta1.CronJob) {
+	err := test.deleteCronJob(cj)
+	test.err(err)
+}
+
+// manualJobName returns the name of a job triggered from a cronjob. The random
+// suffix prevents collisions between triggers and the name is short enough to
+// be used as the job-name label value, limited to 63 characters.
+func manualJobName(cronJob string) string {
+	const suffixLen = 5
+	prefixLen := validation.DNS1123LabelMaxLength - len("-manual-") - suffixLen
+	if len(cronJob) > prefixLen {
+		cronJob = cronJob[:prefixLen]
+	}
+	return cronJob + "-manual-" + utilrand.String(suffixLen)
+}
+
+func (test *Test) triggerCronJob(cj *batchv1beta1.CronJob) (*batchv1.Job, error) {
+	if err := test.needsControllers("TriggerCronJob"); err != nil {
+		return nil, err
+	}
+
+	current, err := test.GetCronJob(cj.Namespace, cj.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// This mirrors kubectl create job --from=cronjob/name.
+	tpl := current.Spec.JobTemplate
+	annotations := map[string]string{"cronjob.kubernetes.io/instantiate": "manual"}
+	for k, v := range tpl.Annotations {
+		annotations[k] = v
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        manualJobName(current.Name),
+			Labels:      tpl.Labels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(current, batchv1beta1.SchemeGroupVersion.WithKind("CronJob")),
+			},
+		},
+		Spec: tpl.Spec,
+	}
+
+	if err := test.createJob(current.Namespace, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// TriggerCronJob runs a cronjob immediately by creating a Job from its job
+// template, as kubectl create job --from=cronjob/name does. It returns the
+// created job.
+func (test *Test) TriggerCronJob(cj *batchv1beta1.CronJob) *batchv1.Job {
+	job, err := test.triggerCronJob(cj)
+	test.err(err)
+	return job
+}
@@ -17,7 +17,7 @@ func (test *Test) createDaemonSet(namespace string, d *appsv1.DaemonSet) error {
 	test.Debugf("creating daemonset %s", d.Name)
 
 	d.Namespace = namespace
-	_, err := test.harness.kubeClient.AppsV1().DaemonSets(namespace).Create(context.TODO(), d, metav1.CreateOptions{})
+	_, err := test.kubeClient.AppsV1().DaemonSets(namespace).Create(context.TODO(), d, metav1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to create daemonset %s: %w", d.Name, err)
 	}
@@ -72,7 +72,7 @@ func (test *Test) CreateDaemonSetFromFile(namespace string, manifestPath string)
 
 // GetDaemonSet returns daemonset if it exists or error if it doesn't.
 func (test *Test) GetDaemonSet(ns, name string) (*appsv1.DaemonSet, error) {
-	d, err := test.harness.kubeClient.AppsV1().DaemonSets(ns).Get(context.TODO(), name, metav1.GetOptions{})
+	d, err := test.kubeClient.AppsV1().DaemonSets(ns).Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -84,6 +84,10 @@ func (test *Test) GetDaemonSet(ns, name string) (*appsv1.DaemonSet, error) {
 func (test *Test) waitForDaemonSetReady(d *appsv1.DaemonSet, timeout time.Duration) error {
 	test.Debugf("waiting for daemonset %s to be ready", d.Name)
 
+	if err := test.needsControllers("WaitForDaemonSetReady"); err != nil {
+		return err
+	}
+
 	return wait.Poll(time.Second, timeout, func() (bool, error) {
 		current, err := test.GetDaemonSet(d.Namespace, d.Name)
 		if err != nil {
@@ -99,11 +103,53 @@ func (test *Test) waitForDaemonSetReady(d *appsv1.DaemonSet, timeout time.Durati
 }
 
 // WaitForDaemonSetReady waits until all replica pods are running and ready.
+// After an update, use WaitForDaemonSetRolledOut instead.
 func (test *Test) WaitForDaemonSetReady(d *appsv1.DaemonSet, timeout time.Duration) {
 	err := test.waitForDaemonSetReady(d, timeout)
 	test.err(err)
 }
 
+// daemonSetRolledOut returns whether a rolling update of d has completed.
+// This follows what kubectl rollout status does.
+func daemonSetRolledOut(d *appsv1.DaemonSet) (bool, error) {
+	if d.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType {
+		return false, fmt.Errorf("daemonset %s: rollout status is only available for the %s strategy", d.Name, appsv1.RollingUpdateDaemonSetStrategyType)
+	}
+	if d.Status.ObservedGeneration < d.Generation {
+		return false, nil
+	}
+	if d.Status.UpdatedNumberScheduled < d.Status.DesiredNumberScheduled {
+		return false, nil
+	}
+	return d.Status.NumberAvailable >= d.Status.DesiredNumberScheduled, nil
+}
+
+// waitForDaemonSetRolledOut waits until a rolling update has completed.
+func (test *Test) waitForDaemonSetRolledOut(d *appsv1.DaemonSet, timeout time.Duration) error {
+	test.Debugf("waiting for daemonset %s to be rolled out", d.Name)
+
+	if err := test.needsControllers("WaitForDaemonSetRolledOut"); err != nil {
+		return err
+	}
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		current, err := test.GetDaemonSet(d.Namespace, d.Name)
+		if err != nil {
+			return false, err
+		}
+		return daemonSetRolledOut(current)
+	})
+}
+
+// WaitForDaemonSetRolledOut waits until a rolling update has completed, ie. the
+// daemonset controller has observed the latest spec and the updated pods are
+// scheduled and available on all the desired nodes. Unlike
+// WaitForDaemonSetReady, it can be used after updating a daemonset.
+func (test *Test) WaitForDaemonSetRolledOut(d *appsv1.DaemonSet, timeout time.Duration) {
+	err := test.waitForDaemonSetRolledOut(d, timeout)
+	test.err(err)
+}
+
 // deleteDaemonSet deletes a daemonset in the given namespace.
 func (test *Test) deleteDaemonSet(d *appsv1.DaemonSet) error {
 	test.Debugf("deleting daemonset %s ", d.Name)
@@ -113,7 +159,7 @@ func (test *Test) deleteDaemonSet(d *appsv1.DaemonSet) error {
 		return err
 	}
 
-	return test.harness.kubeClient.AppsV1().DaemonSets(d.Namespace).Delete(context.TODO(), d.Name, metav1.DeleteOptions{})
+	return test.kubeClient.AppsV1().DaemonSets(d.Namespace).Delete(context.TODO(), d.Name, metav1.DeleteOptions{})
 }
 
 // DeleteDaemonSet deletes a daemonset in the given namespace.
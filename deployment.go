@@ -17,7 +17,7 @@ func (test *Test) createDeployment(namespace string, d *appsv1.Deployment) error
 	test.Debugf("creating deployment %s", d.Name)
 
 	d.Namespace = namespace
-	_, err := test.harness.kubeClient.AppsV1().Deployments(namespace).Create(context.TODO(), d, metav1.CreateOptions{})
+	_, err := test.kubeClient.AppsV1().Deployments(namespace).Create(context.TODO(), d, metav1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to create deployment %s: %w", d.Name, err)
 	}
@@ -72,7 +72,7 @@ func (test *Test) CreateDeploymentFromFile(namespace string, manifestPath string
 
 // GetDeployment returns Deployment if it exists or error if it doesn't.
 func (test *Test) GetDeployment(ns, name string) (*appsv1.Deployment, error) {
-	d, err := test.harness.kubeClient.AppsV1().Deployments(ns).Get(context.TODO(), name, metav1.GetOptions{})
+	d, err := test.kubeClient.AppsV1().Deployments(ns).Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -84,6 +84,10 @@ func (test *Test) GetDeployment(ns, name string) (*appsv1.Deployment, error) {
 func (test *Test) waitForDeploymentReady(d *appsv1.Deployment, timeout time.Duration) error {
 	test.Debugf("waiting for deployment %s to be ready", d.Name)
 
+	if err := test.needsControllers("WaitForDeploymentReady"); err != nil {
+		return err
+	}
+
 	numReady := int32(0)
 
 	return wait.Poll(time.Second, timeout, func() (bool, error) {
@@ -105,28 +109,98 @@ func (test *Test) waitForDeploymentReady(d *appsv1.Deployment, timeout time.Dura
 }
 
 // WaitForDeploymentReady waits until all replica pods are running and ready.
+// After an update, use WaitForDeploymentRolledOut instead.
 func (test *Test) WaitForDeploymentReady(d *appsv1.Deployment, timeout time.Duration) {
 	err := test.waitForDeploymentReady(d, timeout)
 	test.err(err)
 }
 
+// ProgressDeadlineExceededError is returned when a deployment rollout has
+// made no progress for longer than the deployment's progressDeadlineSeconds.
+type ProgressDeadlineExceededError struct {
+	Deployment string
+	Reason     string
+	Message    string
+}
+
+func (e *ProgressDeadlineExceededError) Error() string {
+	return fmt.Sprintf("deployment %s rollout stalled: %s: %s", e.Deployment, e.Reason, e.Message)
+}
+
+func deploymentCondition(d *appsv1.Deployment, condType appsv1.DeploymentConditionType) *appsv1.DeploymentCondition {
+	for i := range d.Status.Conditions {
+		if d.Status.Conditions[i].Type == condType {
+			return &d.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// deploymentRolledOut returns whether the rollout of d has completed. This
+// follows what kubectl rollout status does.
+func deploymentRolledOut(d *appsv1.Deployment) (bool, error) {
+	if d.Status.ObservedGeneration < d.Generation {
+		return false, nil
+	}
+
+	if cond := deploymentCondition(d, appsv1.DeploymentProgressing); cond != nil && cond.Reason == "ProgressDeadlineExceeded" {
+		return false, &ProgressDeadlineExceededError{d.Name, cond.Reason, cond.Message}
+	}
+
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	if d.Status.UpdatedReplicas < replicas {
+		return false, nil
+	}
+	if d.Status.Replicas > d.Status.UpdatedReplicas {
+		// Old replicas are still pending termination.
+		return false, nil
+	}
+	return d.Status.AvailableReplicas >= d.Status.UpdatedReplicas, nil
+}
+
+// waitForDeploymentRolledOut waits until the rollout of a deployment has completed.
+func (test *Test) waitForDeploymentRolledOut(d *appsv1.Deployment, timeout time.Duration) error {
+	test.Debugf("waiting for deployment %s to be rolled out", d.Name)
+
+	if err := test.needsControllers("WaitForDeploymentRolledOut"); err != nil {
+		return err
+	}
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		current, err := test.GetDeployment(d.Namespace, d.Name)
+		if err != nil {
+			return false, err
+		}
+		return deploymentRolledOut(current)
+	})
+}
+
+// WaitForDeploymentRolledOut waits until the rollout of a deployment has
+// completed, ie. the deployment controller has observed the latest spec, all
+// replicas have been updated and are available and no old replica is left.
+// Unlike WaitForDeploymentReady, it can be used after updating a deployment.
+// The test fails with the ProgressDeadlineExceeded reason if the rollout
+// stalls.
+func (test *Test) WaitForDeploymentRolledOut(d *appsv1.Deployment, timeout time.Duration) {
+	err := test.waitForDeploymentRolledOut(d, timeout)
+	test.err(err)
+}
+
 // deleteDeployment deletes a deployment in the given namespace.
 func (test *Test) deleteDeployment(d *appsv1.Deployment) error {
 	test.Debugf("deleting deployment %s ", d.Name)
 
-	d, err := test.GetDeployment(d.Namespace, d.Name)
+	client, err := test.objectScaleClient(d)
 	if err != nil {
 		return err
 	}
-
-	zero := int32(0)
-	d.Spec.Replicas = &zero
-
-	d, err = test.harness.kubeClient.AppsV1().Deployments(d.Namespace).Update(context.TODO(), d, metav1.UpdateOptions{})
-	if err != nil {
+	if _, err := test.scale(client, 0); err != nil {
 		return err
 	}
-	return test.harness.kubeClient.AppsV1().Deployments(d.Namespace).Delete(context.TODO(), d.Name, metav1.DeleteOptions{})
+	return test.kubeClient.AppsV1().Deployments(d.Namespace).Delete(context.TODO(), d.Name, metav1.DeleteOptions{})
 }
 
 // DeleteDeployment deletes a deployment in the given namespace.
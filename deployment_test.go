@@ -0,0 +1,83 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeploymentRolledOut(t *testing.T) {
+	newDeployment := func(status appsv1.DeploymentStatus) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(3)},
+			Status:     status,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		status   appsv1.DeploymentStatus
+		expected bool
+	}{
+		{"not observed", appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}, false},
+		{"updating", appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 1, AvailableReplicas: 3}, false},
+		{"old replicas left", appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 3, AvailableReplicas: 3}, false},
+		{"not available", appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 2}, false},
+		{"rolled out", appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}, true},
+	}
+
+	for _, test := range tests {
+		done, err := deploymentRolledOut(newDeployment(test.status))
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.expected, done, test.name)
+	}
+
+	stalled := newDeployment(appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Conditions: []appsv1.DeploymentCondition{{
+			Type:    appsv1.DeploymentProgressing,
+			Reason:  "ProgressDeadlineExceeded",
+			Message: `ReplicaSet "web-1234" has timed out progressing.`,
+		}},
+	})
+	_, err := deploymentRolledOut(stalled)
+	assert.IsType(t, &ProgressDeadlineExceededError{}, err)
+	assert.Contains(t, err.Error(), "ProgressDeadlineExceeded")
+}
+
+func TestDaemonSetRolledOut(t *testing.T) {
+	newDaemonSet := func(status appsv1.DaemonSetStatus) *appsv1.DaemonSet {
+		return &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "agent", Generation: 2},
+			Spec: appsv1.DaemonSetSpec{
+				UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType},
+			},
+			Status: status,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		status   appsv1.DaemonSetStatus
+		expected bool
+	}{
+		{"not observed", appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3}, false},
+		{"updating", appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 1, NumberAvailable: 3}, false},
+		{"not available", appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2}, false},
+		{"rolled out", appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3}, true},
+	}
+
+	for _, test := range tests {
+		done, err := daemonSetRolledOut(newDaemonSet(test.status))
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.expected, done, test.name)
+	}
+
+	onDelete := newDaemonSet(appsv1.DaemonSetStatus{})
+	onDelete.Spec.UpdateStrategy.Type = appsv1.OnDeleteDaemonSetStrategyType
+	_, err := daemonSetRolledOut(onDelete)
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package harness
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// newExecutor creates the executor running commands in containers. Unit tests
+// replace it to use a fake executor.
+var newExecutor = remotecommand.NewSPDYExecutor
+
+func (test *Test) podExec(pod *v1.Pod, containerName string, command []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if err := test.needsControllers("PodExec"); err != nil {
+		return err
+	}
+
+	containerName, err := podContainerName(pod, containerName)
+	if err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+
+	test.Debugf("executing '%s' in pod %s, container %s", strings.Join(command, " "), pod.Name, containerName)
+
+	req := test.kubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: containerName,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    stdout != nil,
+			Stderr:    stderr != nil,
+		}, scheme.ParameterCodec)
+
+	executor, err := newExecutor(test.restConfig, http.MethodPost, req.URL())
+	if err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+
+	return executor.Stream(remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
+
+// PodExec runs command in a container of pod and returns what the command has
+// written on its standard output. If the pod has a single container,
+// containerName is optional and can be set to "".
+//
+// The test fails if the command cannot be run or exits with a non-zero status.
+func (test *Test) PodExec(pod *v1.Pod, containerName string, command ...string) string {
+	var stdout, stderr bytes.Buffer
+
+	if err := test.podExec(pod, containerName, command, nil, &stdout, &stderr); err != nil {
+		test.err(fmt.Errorf("exec '%s' in pod %s failed: %w: %s", strings.Join(command, " "), pod.Name, err, stderr.String()))
+	}
+	return stdout.String()
+}
@@ -0,0 +1,36 @@
+package harness
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dlespiau/kube-test-harness/logger"
+	harnesstesting "github.com/dlespiau/kube-test-harness/testing"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newFakeTest returns a test using a fake clientset populated with objects.
+func newFakeTest(t *testing.T, objects ...runtime.Object) (*Test, *fake.Clientset) {
+	client := fake.NewSimpleClientset(objects...)
+	return &Test{
+		ID:         "rbac",
+		kubeClient: client,
+		t:          t,
+		logger:     (&logger.TestLogger{}).ForTest(t),
+	}, client
+}
+
+// recordingT records the errors reported to it instead of failing the test.
+type recordingT struct {
+	harnesstesting.T
+	errors []string
+}
+
+func (t *recordingT) Error(args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprint(args...))
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
@@ -2,8 +2,10 @@ package harness
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/dlespiau/kube-test-harness/logger"
 	"github.com/dlespiau/kube-test-harness/testing"
@@ -21,14 +23,36 @@ func homeDirectory() string {
 }
 
 // newClientConfig returns a configuration object that can be used to configure
-// a client in order to contact an API server with.
-func newClientConfig(kubeconfig string) (*rest.Config, error) {
+// a client in order to contact an API server with. An empty context means the
+// kubeconfig current context.
+func newClientConfig(kubeconfig, context string) (*rest.Config, error) {
 	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
 		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
-		&clientcmd.ConfigOverrides{},
+		&clientcmd.ConfigOverrides{CurrentContext: context},
 	).ClientConfig()
 }
 
+// ClusterOptions configure how to access an additional cluster, see
+// Options.Clusters.
+type ClusterOptions struct {
+	// Kubeconfig is the path to a kubeconfig file. If not given, defaults to
+	// the kubeconfig of the harness.
+	Kubeconfig string
+	// Context is the kubeconfig context to use. If not given, defaults to
+	// the kubeconfig current context.
+	Context string
+	// ControlPlaneOnly indicates the cluster only runs an API server and
+	// etcd. See Options.ControlPlaneOnly.
+	ControlPlaneOnly bool
+}
+
+// cluster is an additional cluster tests can target with Test.Cluster.
+type cluster struct {
+	restConfig       *rest.Config
+	apiServer        string
+	controlPlaneOnly bool
+}
+
 // Options are configuration options for the test harness.
 type Options struct {
 	// Kubeconfig is the path to a kubeconfig file. If not given, Harness will
@@ -39,8 +63,48 @@ type Options struct {
 	// are located. It can be an absolute path a or path relative to the directory
 	// where the test is. If not given, defaults to the current working directory.
 	ManifestDirectory string
+	// ArtifactDirectory is the root directory where tests can save artifacts,
+	// eg. files copied out of pods. Each test gets its own sub-directory, named
+	// after the test ID. It can be an absolute path or a path relative to the
+	// directory where the test is. If not given, artifacts are not saved.
+	ArtifactDirectory string
+	// Clusters are additional named clusters tests can target, eg. to test
+	// replication across clusters. See Test.Cluster.
+	Clusters map[string]ClusterOptions
+	// ClusterProvider, when given, creates the cluster the tests run against
+	// in Setup and deletes it in Close. Kubeconfig is then set to the
+	// kubeconfig of the created cluster.
+	ClusterProvider ClusterProvider
+	// ReuseCluster makes Setup use the cluster of ClusterProvider if it
+	// already exists instead of creating it. A reused cluster isn't deleted by
+	// Close.
+	ReuseCluster bool
+	// KeepCluster prevents Close from deleting the cluster created by
+	// ClusterProvider, eg. to investigate a failure or to reuse it in the next
+	// run.
+	KeepCluster bool
+	// ControlPlaneOnly indicates the cluster is made of an API server and etcd
+	// only, without controller manager, scheduler nor nodes. Helpers waiting
+	// for objects to be reconciled, eg. WaitForDeploymentReady, then fail
+	// immediately with a NoControllersError rather than timing out. It's set
+	// automatically by cluster providers such as provider.ControlPlane. It
+	// only applies to the main cluster, see ClusterOptions.ControlPlaneOnly
+	// for the clusters of Options.Clusters.
+	ControlPlaneOnly bool
+	// Images are container images of the local container runtime to load in
+	// the cluster of ClusterProvider once created. See Harness.LoadImage.
+	Images []string
+	// RetryPolicy configures the retries of API calls failing with a transient
+	// error, eg. while the API server restarts. Defaults to
+	// DefaultRetryPolicy.
+	RetryPolicy *RetryPolicy
 	// NoCleanup controls if tests should cleanup after them.
 	NoCleanup bool
+	// AllowPodRestarts controls if tests should fail when a container of a pod
+	// in the test namespaces has restarted. By default, such restarts are
+	// reported when the test is closed and the test fails. See
+	// Test.AllowPodRestarts to allow restarts in a single test.
+	AllowPodRestarts bool
 	// Logger is the Logger used to dispay test logs. If not given, Harness will
 	// use logger.TestLogger which uses the logging built in the testing package.
 	// This logger will only display logs on error or when -v is given to go test.
@@ -58,8 +122,14 @@ type Options struct {
 // Harness is a test harness for running integration tests on a kubernetes cluster.
 type Harness struct {
 	options    Options
+	restConfig *rest.Config
 	kubeClient kubernetes.Interface
 	apiServer  string
+	clusters   map[string]*cluster
+
+	// clusterCreated is set when the cluster of Options.ClusterProvider has
+	// been created by the harness.
+	clusterCreated bool
 }
 
 // New creates a new test harness.
@@ -104,11 +174,33 @@ func (h *Harness) Setup() error {
 	if err != nil {
 		return err
 	}
+	if h.options.ArtifactDirectory != "" {
+		h.options.ArtifactDirectory, err = resolveDirectory(h.options.ArtifactDirectory)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Cluster
+	if err := h.createCluster(); err != nil {
+		return err
+	}
+	for _, image := range h.options.Images {
+		if err := h.LoadImage(image); err != nil {
+			return err
+		}
+	}
 
 	// It's possible we don't have a kubeconfig file at Setup time. We hope someone
 	// will call SetKubeconfig at a later point when the location of kubeconfig is
 	// known.
-	h.SetKubeconfig("")
+	h.SetKubeconfig(h.options.Kubeconfig)
+
+	for name, options := range h.options.Clusters {
+		if err := h.AddCluster(name, options); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -141,15 +233,20 @@ func (h *Harness) SetKubeconfig(kubeconfigPath string) error {
 
 	h.options.Kubeconfig = kubeconfigPath
 
-	// Kubernetes client
-	config, err := newClientConfig(h.options.Kubeconfig)
+	// Kubernetes client. Tests create their own client from restConfig, the
+	// harness client retries API calls as the test clients do.
+	config, err := newClientConfig(h.options.Kubeconfig, "")
 	if err != nil {
 		return err
 	}
-	h.kubeClient, err = kubernetes.NewForConfig(config)
+	policy := h.retryPolicy()
+	h.kubeClient, err = kubernetes.NewForConfig(retryConfig(config, policy, func(req *http.Request, reason string, retry int, delay time.Duration) {
+		h.options.Logger.Logf(logger.Debug, "retrying %s %s in %s (%d/%d): %s", req.Method, req.URL.Path, delay, retry, policy.MaxRetries, reason)
+	}))
 	if err != nil {
 		return err
 	}
+	h.restConfig = config
 	h.apiServer = config.Host
 
 	h.options.Logger.Logf(logger.Info, "using kubeconfig: %s", kubeconfigPath)
@@ -157,11 +254,41 @@ func (h *Harness) SetKubeconfig(kubeconfigPath string) error {
 	return nil
 }
 
-// Close terminates a test harness and frees its resources.
-func (h *Harness) Close() error {
+// AddCluster registers an additional cluster tests can target with
+// Test.Cluster(name).
+func (h *Harness) AddCluster(name string, options ClusterOptions) error {
+	kubeconfigPath := options.Kubeconfig
+	if kubeconfigPath == "" {
+		kubeconfigPath = h.options.Kubeconfig
+	}
+	if kubeconfigPath == "" {
+		kubeconfigPath = defaultKubeconfigPath()
+	}
+
+	config, err := newClientConfig(kubeconfigPath, options.Context)
+	if err != nil {
+		return fmt.Errorf("cluster %s: %w", name, err)
+	}
+	if h.clusters == nil {
+		h.clusters = make(map[string]*cluster)
+	}
+	h.clusters[name] = &cluster{
+		restConfig:       config,
+		apiServer:        config.Host,
+		controlPlaneOnly: options.ControlPlaneOnly,
+	}
+
+	h.options.Logger.Logf(logger.Info, "using kubeconfig %s for cluster %s", kubeconfigPath, name)
+
 	return nil
 }
 
+// Close terminates a test harness and frees its resources, including the
+// cluster created by Options.ClusterProvider.
+func (h *Harness) Close() error {
+	return h.deleteCluster()
+}
+
 func (h *Harness) openManifest(manifest string) (*os.File, error) {
 	path := filepath.Join(h.options.ManifestDirectory, manifest)
 	f, err := os.Open(path)
@@ -174,12 +301,19 @@ func (h *Harness) openManifest(manifest string) (*os.File, error) {
 
 // Run setup the test harness and run the tests with m.Run.
 func (h *Harness) Run(m *testing.M) int {
+	return h.run(m.Run)
+}
+
+func (h *Harness) run(tests func() int) int {
 	if err := h.Setup(); err != nil {
 		h.options.Logger.Logf(logger.Info, "failed to initialize test harness: %v", err)
+		if err := h.Close(); err != nil {
+			h.options.Logger.Logf(logger.Info, "failed to teardown test harness: %v", err)
+		}
 		return 1
 	}
 
-	code := m.Run()
+	code := tests()
 
 	if err := h.Close(); err != nil {
 		h.options.Logger.Logf(logger.Info, "failed to teardown test harness: %v", err)
@@ -0,0 +1,151 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+)
+
+// ForbiddenError is the error of an API call denied by the API server. Views
+// created with As or AsServiceAccount report denied calls with it.
+type ForbiddenError struct {
+	// User is the impersonated identity, empty if the call was made with the
+	// kubeconfig identity.
+	User string
+	Err  error
+}
+
+func (e *ForbiddenError) Error() string {
+	if e.User == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("as %s: %v", e.User, e.Err)
+}
+
+func (e *ForbiddenError) Unwrap() error {
+	return e.Err
+}
+
+// forbidden turns forbidden API errors into ForbiddenErrors.
+func (test *Test) forbidden(err error) error {
+	if !apierrors.IsForbidden(err) {
+		return err
+	}
+	return &ForbiddenError{User: test.user, Err: err}
+}
+
+// catch records the first ForbiddenError reported while running
+// Test.Forbidden and returns whether err has been handled.
+func (test *Test) catch(err error) bool {
+	var denied *ForbiddenError
+	if !test.catchForbidden || !errors.As(err, &denied) {
+		return false
+	}
+	if test.denied == nil {
+		test.denied = denied
+	}
+	return true
+}
+
+// view returns a Test sharing the state of test but making API calls with
+// config.
+func (test *Test) view(config *rest.Config, user string) (*Test, error) {
+	client, err := newClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Test{
+		ID:          test.ID,
+		Namespace:   test.Namespace,
+		harness:     test.harness,
+		kubeClient:  client,
+		restConfig:  config,
+		t:           test.t,
+		logger:      test.logger,
+		parent:      test,
+		user:        user,
+		clusterName: test.clusterName,
+	}, nil
+}
+
+func (test *Test) as(user string, groups []string) (*Test, error) {
+	config := rest.CopyConfig(test.restConfig)
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: user,
+		Groups:   groups,
+	}
+	return test.view(config, user)
+}
+
+// As returns a view of the test whose API calls impersonate user, member of
+// groups. The kubeconfig identity needs to be allowed to impersonate users and
+// groups.
+//
+// The view shares the namespace, the created objects and the cleanup of test:
+// objects created through the view are deleted when test is closed and the view
+// itself doesn't need to be closed. Denied API calls fail the test with a
+// ForbiddenError, use Forbidden or AssertForbidden to check an action is
+// denied.
+func (test *Test) As(user string, groups []string) *Test {
+	view, err := test.as(user, groups)
+	test.err(err)
+	return view
+}
+
+// serviceAccountUser returns the user name and groups of a service account.
+func serviceAccountUser(sa *v1.ServiceAccount) (string, []string) {
+	user := fmt.Sprintf("system:serviceaccount:%s:%s", sa.Namespace, sa.Name)
+	groups := []string{
+		"system:serviceaccounts",
+		"system:serviceaccounts:" + sa.Namespace,
+		"system:authenticated",
+	}
+	return user, groups
+}
+
+// AsServiceAccount returns a view of the test whose API calls impersonate a
+// service account, ie. the identity of the workloads running with that service
+// account. See As.
+func (test *Test) AsServiceAccount(sa *v1.ServiceAccount) *Test {
+	user, groups := serviceAccountUser(sa)
+	return test.As(user, groups)
+}
+
+// Forbidden runs fn and returns the ForbiddenError of the first API call of
+// fn denied by the API server, or nil if fn completes without being denied.
+// Denied calls don't fail the test, the helper making the call returns as if
+// it had succeeded so the rest of fn shouldn't rely on its result. fn needs to
+// make its calls through test, eg.:
+//
+//	alice := test.As("alice", nil)
+//	err := alice.Forbidden(func() {
+//	    alice.CreateDeployment(test.Namespace, deployment)
+//	})
+//
+// Other errors fail the test as usual.
+func (test *Test) Forbidden(fn func()) *ForbiddenError {
+	test.catchForbidden, test.denied = true, nil
+	defer func() {
+		test.catchForbidden, test.denied = false, nil
+	}()
+
+	fn()
+	return test.denied
+}
+
+// AssertForbidden checks an API call made by fn is denied. See Forbidden.
+func (test *Test) AssertForbidden(fn func()) {
+	test.t.Helper()
+
+	if test.Forbidden(fn) == nil {
+		user := test.user
+		if user == "" {
+			user = "the kubeconfig identity"
+		}
+		test.err(fmt.Errorf("expected %s to be forbidden, but the action was allowed", user))
+	}
+}
@@ -0,0 +1,95 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dlespiau/kube-test-harness/logger"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+func TestAs(t *testing.T) {
+	test := &Test{
+		ID:         "as",
+		Namespace:  "as-ns-1",
+		t:          t,
+		logger:     (&logger.TestLogger{}).ForTest(t),
+		restConfig: &rest.Config{Host: "https://127.0.0.1:6443"},
+	}
+
+	alice, err := test.as("alice", []string{"dev"})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", alice.restConfig.Impersonate.UserName)
+	assert.Equal(t, []string{"dev"}, alice.restConfig.Impersonate.Groups)
+	assert.Equal(t, "", test.restConfig.Impersonate.UserName)
+	assert.Equal(t, test.Namespace, alice.Namespace)
+
+	// Views share the state of the test they have been created from.
+	alice.addNamespace("ns")
+	alice.addFinalizer(func() error { return nil })
+	assert.Equal(t, []string{"ns"}, test.namespaces)
+	assert.Len(t, test.cleanUpFns, 1)
+	assert.Equal(t, alice.getObjID("pod"), "as-pod-1")
+	assert.Equal(t, test.getObjID("pod"), "as-pod-2")
+}
+
+func TestServiceAccountUser(t *testing.T) {
+	sa := &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app"}}
+	user, groups := serviceAccountUser(sa)
+	assert.Equal(t, "system:serviceaccount:ns:app", user)
+	assert.Contains(t, groups, "system:serviceaccounts:ns")
+}
+
+func TestForbidden(t *testing.T) {
+	test := &Test{user: "alice"}
+	denied := apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "foo", errors.New("rbac"))
+
+	err := test.forbidden(denied)
+	var fe *ForbiddenError
+	assert.True(t, errors.As(err, &fe))
+	assert.Equal(t, "alice", fe.User)
+	assert.True(t, apierrors.IsForbidden(err))
+
+	other := errors.New("other")
+	assert.Equal(t, other, test.forbidden(other))
+
+	calls := 0
+	fe = test.Forbidden(func() {
+		test.err(denied)
+		calls++
+		test.err(apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "bar", errors.New("rbac")))
+	})
+	assert.Equal(t, 1, calls)
+	assert.NotNil(t, fe)
+	assert.Contains(t, fe.Error(), "pods")
+	assert.False(t, test.catchForbidden)
+	assert.False(t, test.inError)
+
+	assert.Nil(t, test.Forbidden(func() {}))
+}
+
+func TestViewCleanupAsRoot(t *testing.T) {
+	test, client := newFakeTest(t)
+	alice, aliceClient := newFakeTest(t)
+	alice.parent, alice.user = test, "alice"
+
+	alice.CreateNamespace("alice-ns")
+	_, err := client.CoreV1().Namespaces().Create(context.TODO(), &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice-ns"},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	// The finalizer registered through the view uses the client of the test.
+	assert.Len(t, test.cleanUpFns, 1)
+	assert.NoError(t, test.cleanUpFns[0]())
+	_, err = client.CoreV1().Namespaces().Get(context.TODO(), "alice-ns", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = aliceClient.CoreV1().Namespaces().Get(context.TODO(), "alice-ns", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,299 @@
+package harness
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func (test *Test) createIngress(namespace string, ingress *networkingv1.Ingress) error {
+	test.Debugf("creating ingress %s", ingress.Name)
+
+	ingress.Namespace = namespace
+	if _, err := test.kubeClient.NetworkingV1().Ingresses(namespace).Create(context.TODO(), ingress, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create ingress %s: %w", ingress.Name, err)
+	}
+	return nil
+}
+
+// CreateIngress creates an ingress in the given namespace.
+func (test *Test) CreateIngress(namespace string, ingress *networkingv1.Ingress) {
+	err := test.createIngress(namespace, ingress)
+	test.err(err)
+}
+
+func (test *Test) getIngress(namespace, name string) (*networkingv1.Ingress, error) {
+	ingress, err := test.kubeClient.NetworkingV1().Ingresses(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ingress %s: %w", name, err)
+	}
+	return ingress, nil
+}
+
+// GetIngress retrieves an ingress with a given name and namespace.
+func (test *Test) GetIngress(namespace, name string) *networkingv1.Ingress {
+	ingress, err := test.getIngress(namespace, name)
+	test.err(err)
+	return ingress
+}
+
+func (test *Test) loadIngress(manifestPath string) (*networkingv1.Ingress, error) {
+	manifest, err := test.harness.openManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	ingress := networkingv1.Ingress{}
+	if err := yaml.NewYAMLOrJSONDecoder(manifest, 100).Decode(&ingress); err != nil {
+		return nil, fmt.Errorf("failed to decode ingress %s: %w", manifestPath, err)
+	}
+
+	return &ingress, nil
+}
+
+// LoadIngress loads an ingress from a YAML manifest. The path to the manifest
+// is relative to Harness.ManifestDirectory.
+func (test *Test) LoadIngress(manifestPath string) *networkingv1.Ingress {
+	ingress, err := test.loadIngress(manifestPath)
+	test.err(err)
+	return ingress
+}
+
+func (test *Test) createIngressFromFile(namespace string, manifestPath string) (*networkingv1.Ingress, error) {
+	ingress, err := test.loadIngress(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	err = test.createIngress(namespace, ingress)
+	if err != nil {
+		return nil, err
+	}
+	return ingress, nil
+}
+
+// CreateIngressFromFile creates an ingress from a manifest file in the given namespace.
+func (test *Test) CreateIngressFromFile(namespace string, manifestPath string) *networkingv1.Ingress {
+	ingress, err := test.createIngressFromFile(namespace, manifestPath)
+	test.err(err)
+	return ingress
+}
+
+func (test *Test) updateIngress(ingress *networkingv1.Ingress) error {
+	test.Debugf("updating ingress %s", ingress.Name)
+
+	if _, err := test.kubeClient.NetworkingV1().Ingresses(ingress.Namespace).Update(context.TODO(), ingress, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("updating ingress %v failed: %w", ingress.Name, err)
+	}
+	return nil
+}
+
+// UpdateIngress updates an ingress.
+func (test *Test) UpdateIngress(ingress *networkingv1.Ingress) {
+	err := test.updateIngress(ingress)
+	test.err(err)
+}
+
+func (test *Test) deleteIngress(ingress *networkingv1.Ingress) error {
+	test.Debugf("deleting ingress %s", ingress.Name)
+
+	if err := test.kubeClient.NetworkingV1().Ingresses(ingress.Namespace).Delete(context.TODO(), ingress.Name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("deleting ingress %v failed: %w", ingress.Name, err)
+	}
+	return nil
+}
+
+// DeleteIngress deletes an ingress.
+func (test *Test) DeleteIngress(ingress *networkingv1.Ingress) {
+	err := test.deleteIngress(ingress)
+	test.err(err)
+}
+
+func (test *Test) waitForIngressDeleted(ingress *networkingv1.Ingress, timeout time.Duration) error {
+	test.Debugf("waiting for ingress %s to be deleted", ingress.Name)
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		_, err := test.kubeClient.NetworkingV1().Ingresses(ingress.Namespace).Get(context.TODO(), ingress.Name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		}
+		return false, nil
+	})
+}
+
+// WaitForIngressDeleted waits until a deleted ingress has disappeared from the cluster.
+func (test *Test) WaitForIngressDeleted(ingress *networkingv1.Ingress, timeout time.Duration) {
+	test.err(test.waitForIngressDeleted(ingress, timeout))
+}
+
+// ingressAddress returns the first address found in the ingress load balancer
+// status or "".
+func ingressAddress(ingress *networkingv1.Ingress) string {
+	for _, lb := range ingress.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			return lb.IP
+		}
+		if lb.Hostname != "" {
+			return lb.Hostname
+		}
+	}
+	return ""
+}
+
+func (test *Test) waitForIngressLoadBalancer(ingress *networkingv1.Ingress, timeout time.Duration) (string, error) {
+	test.Debugf("waiting for ingress %s load balancer", ingress.Name)
+
+	if err := test.needsControllers("WaitForIngressLoadBalancer"); err != nil {
+		return "", err
+	}
+
+	address := ""
+	err := wait.Poll(time.Second, timeout, func() (bool, error) {
+		current, err := test.getIngress(ingress.Namespace, ingress.Name)
+		if err != nil {
+			return false, err
+		}
+		address = ingressAddress(current)
+		return address != "", nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("waiting for ingress %s load balancer failed: %w", ingress.Name, err)
+	}
+
+	test.Debugf("ingress %s address: %s", ingress.Name, address)
+	return address, nil
+}
+
+// WaitForIngressLoadBalancer waits until the ingress controller has populated
+// the load balancer status of an ingress and returns the ingress IP address or
+// hostname.
+func (test *Test) WaitForIngressLoadBalancer(ingress *networkingv1.Ingress, timeout time.Duration) string {
+	address, err := test.waitForIngressLoadBalancer(ingress, timeout)
+	test.err(err)
+	return address
+}
+
+// IngressRequest is an HTTP request sent through an ingress.
+type IngressRequest struct {
+	// Address is the host or host:port to connect to. If not given, the address
+	// found in the ingress load balancer status is used. This is useful with
+	// local clusters where the ingress controller is exposed on localhost or on
+	// a node port.
+	Address string
+	// Host is the value of the Host header, used by the ingress controller to
+	// route the request. If not given, the host of the first ingress rule is
+	// used.
+	Host string
+	// Scheme is either "http" or "https". If not given, defaults to "http".
+	// Certificates aren't verified.
+	Scheme string
+	// Method is the HTTP method. If not given, defaults to GET.
+	Method string
+	// Path is the request path, including an optional query string.
+	Path string
+	// Header holds the request headers.
+	Header http.Header
+	// Body is the request body.
+	Body []byte
+}
+
+func (test *Test) ingressDo(ingress *networkingv1.Ingress, req *IngressRequest) (*ProxyResponse, error) {
+	address := req.Address
+	if address == "" {
+		current, err := test.getIngress(ingress.Namespace, ingress.Name)
+		if err != nil {
+			return nil, err
+		}
+		if address = ingressAddress(current); address == "" {
+			return nil, fmt.Errorf("ingress %s has no load balancer address", ingress.Name)
+		}
+	}
+
+	host := req.Host
+	if host == "" && len(ingress.Spec.Rules) > 0 {
+		host = ingress.Spec.Rules[0].Host
+	}
+
+	scheme := req.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	test.Debugf("ingress: %s %s://%s%s (host: %s)", method, scheme, address, req.Path, host)
+
+	httpReq, err := http.NewRequest(method, scheme+"://"+address+req.Path, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, fmt.Errorf("ingress: %w", err)
+	}
+	for k, values := range req.Header {
+		for _, v := range values {
+			httpReq.Header.Add(k, v)
+		}
+	}
+	if host != "" {
+		httpReq.Host = host
+	}
+
+	serverName := host
+	if serverName == "" {
+		serverName, _, err = net.SplitHostPort(address)
+		if err != nil {
+			serverName = address
+		}
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				ServerName:         serverName,
+				InsecureSkipVerify: true,
+			},
+		},
+		Timeout: 30 * time.Second,
+	}
+	// The TLS server name depends on the request, so each request has its own
+	// transport. Don't leave its connection behind.
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ingress: %s %s failed: %w", method, req.Path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ingress: failed to read response body: %w", err)
+	}
+
+	return &ProxyResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}, nil
+}
+
+// IngressDo sends an HTTP request through an ingress, setting the Host header
+// so the ingress controller routes the request according to the ingress rules.
+// Responses with a non-2xx status code are not considered errors and are
+// returned to the caller.
+func (test *Test) IngressDo(ingress *networkingv1.Ingress, req *IngressRequest) *ProxyResponse {
+	resp, err := test.ingressDo(ingress, req)
+	test.err(err)
+	return resp
+}
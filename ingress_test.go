@@ -0,0 +1,49 @@
+package harness
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dlespiau/kube-test-harness/logger"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func TestIngressAddress(t *testing.T) {
+	ingress := &networkingv1.Ingress{}
+	assert.Equal(t, "", ingressAddress(ingress))
+
+	ingress.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{Hostname: "lb.example.com"}}
+	assert.Equal(t, "lb.example.com", ingressAddress(ingress))
+
+	ingress.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "10.0.0.1", Hostname: "lb.example.com"}}
+	assert.Equal(t, "10.0.0.1", ingressAddress(ingress))
+}
+
+func TestIngressDoHostHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(r.Host + " " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	test := &Test{t: t, logger: (&logger.TestLogger{}).ForTest(t)}
+	ingress := &networkingv1.Ingress{
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{Host: "app.example.com"}},
+		},
+	}
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	resp, err := test.ingressDo(ingress, &IngressRequest{Address: address, Path: "/foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+	assert.Equal(t, "app.example.com /foo", string(resp.Body))
+
+	resp, err = test.ingressDo(ingress, &IngressRequest{Address: address, Host: "other.example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "other.example.com /", string(resp.Body))
+}
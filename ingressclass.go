@@ -0,0 +1,87 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func (test *Test) createIngressClass(ic *networkingv1.IngressClass) error {
+	test.Debugf("creating ingress class %s", ic.Name)
+
+	if _, err := test.kubeClient.NetworkingV1().IngressClasses().Create(context.TODO(), ic, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create ingress class %s: %w", ic.Name, err)
+	}
+	return nil
+}
+
+// CreateIngressClass creates an ingress class.
+func (test *Test) CreateIngressClass(ic *networkingv1.IngressClass) {
+	if err := test.createIngressClass(ic); err != nil {
+		test.err(err)
+		return
+	}
+
+	root := test.root()
+	test.addFinalizer(func() error {
+		return root.deleteIngressClass(ic.Name)
+	})
+}
+
+func (test *Test) loadIngressClass(manifestPath string) (*networkingv1.IngressClass, error) {
+	manifest, err := test.harness.openManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	ic := networkingv1.IngressClass{}
+	if err := yaml.NewYAMLOrJSONDecoder(manifest, 100).Decode(&ic); err != nil {
+		return nil, fmt.Errorf("failed to decode ingress class %s: %w", manifestPath, err)
+	}
+	return &ic, nil
+}
+
+// LoadIngressClass loads an ingress class from a YAML manifest. The path to the
+// manifest is relative to Harness.ManifestDirectory.
+func (test *Test) LoadIngressClass(manifestPath string) *networkingv1.IngressClass {
+	ic, err := test.loadIngressClass(manifestPath)
+	test.err(err)
+	return ic
+}
+
+// CreateIngressClassFromFile creates an ingress class from a manifest file.
+func (test *Test) CreateIngressClassFromFile(manifestPath string) *networkingv1.IngressClass {
+	ic, err := test.loadIngressClass(manifestPath)
+	if err != nil {
+		test.err(err)
+		return nil
+	}
+	test.CreateIngressClass(ic)
+	return ic
+}
+
+func (test *Test) deleteIngressClass(name string) error {
+	test.Debugf("deleting ingress class %s", name)
+
+	if err := test.kubeClient.NetworkingV1().IngressClasses().Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("deleting ingress class %s failed: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteIngressClass deletes an ingress class.
+func (test *Test) DeleteIngressClass(ic *networkingv1.IngressClass) {
+	err := test.deleteIngressClass(ic.Name)
+	test.err(err)
+}
+
+// GetIngressClass returns an IngressClass object if it exists or error.
+func (test *Test) GetIngressClass(name string) (*networkingv1.IngressClass, error) {
+	ic, err := test.kubeClient.NetworkingV1().IngressClasses().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return ic, nil
+}
@@ -0,0 +1,254 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func (test *Test) createJob(namespace string, job *batchv1.Job) error {
+	test.Debugf("creating job %s", job.Name)
+
+	job.Namespace = namespace
+	if _, err := test.kubeClient.BatchV1().Jobs(namespace).Create(context.TODO(), job, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create job %s: %w", job.Name, err)
+	}
+	return nil
+}
+
+// CreateJob creates a job in the given namespace.
+func (test *Test) CreateJob(namespace string, job *batchv1.Job) {
+	err := test.createJob(namespace, job)
+	test.err(err)
+}
+
+func (test *Test) loadJob(manifestPath string) (*batchv1.Job, error) {
+	manifest, err := test.harness.openManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	job := batchv1.Job{}
+	if err := yaml.NewYAMLOrJSONDecoder(manifest, 100).Decode(&job); err != nil {
+		return nil, fmt.Errorf("failed to decode job %s: %w", manifestPath, err)
+	}
+
+	return &job, nil
+}
+
+// LoadJob loads a job from a YAML manifest. The path to the manifest is
+// relative to Harness.ManifestDirectory.
+func (test *Test) LoadJob(manifestPath string) *batchv1.Job {
+	job, err := test.loadJob(manifestPath)
+	test.err(err)
+	return job
+}
+
+func (test *Test) createJobFromFile(namespace string, manifestPath string) (*batchv1.Job, error) {
+	job, err := test.loadJob(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	err = test.createJob(namespace, job)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// CreateJobFromFile creates a job from a manifest file in the given namespace.
+func (test *Test) CreateJobFromFile(namespace string, manifestPath string) *batchv1.Job {
+	job, err := test.createJobFromFile(namespace, manifestPath)
+	test.err(err)
+	return job
+}
+
+// GetJob returns a Job object if it exists or error.
+func (test *Test) GetJob(ns, name string) (*batchv1.Job, error) {
+	job, err := test.kubeClient.BatchV1().Jobs(ns).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (test *Test) deleteJob(job *batchv1.Job) error {
+	test.Debugf("deleting job %s", job.Name)
+
+	// Jobs orphan their pods by default.
+	propagation := metav1.DeletePropagationBackground
+	if err := test.kubeClient.BatchV1().Jobs(job.Namespace).Delete(context.TODO(), job.Name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	}); err != nil {
+		return fmt.Errorf("deleting job %s failed: %w", job.Name, err)
+	}
+	return nil
+}
+
+// DeleteJob deletes a job and its pods.
+func (test *Test) DeleteJob(job *batchv1.Job) {
+	err := test.deleteJob(job)
+	test.err(err)
+}
+
+// waitForJobDeleted waits until a deleted job has disappeared from the cluster.
+func (test *Test) waitForJobDeleted(job *batchv1.Job, timeout time.Duration) error {
+	test.Debugf("waiting for job %s to be deleted", job.Name)
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		_, err := test.GetJob(job.Namespace, job.Name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+
+			return false, err
+		}
+
+		return false, nil
+	})
+}
+
+// WaitForJobDeleted waits until a deleted job has disappeared from the cluster.
+func (test *Test) WaitForJobDeleted(job *batchv1.Job, timeout time.Duration) {
+	test.err(test.waitForJobDeleted(job, timeout))
+}
+
+// jobCondition returns the condition of the given type if it's true.
+func jobCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) *batchv1.JobCondition {
+	for i := range job.Status.Conditions {
+		cond := &job.Status.Conditions[i]
+		if cond.Type == conditionType && cond.Status == v1.ConditionTrue {
+			return cond
+		}
+	}
+	return nil
+}
+
+// JobFailedError is returned when a job has failed while waiting for it to
+// complete.
+type JobFailedError struct {
+	Job     string
+	Reason  string
+	Message string
+}
+
+func (e *JobFailedError) Error() string {
+	return fmt.Sprintf("job %s failed: %s: %s", e.Job, e.Reason, e.Message)
+}
+
+func (test *Test) waitForJobComplete(job *batchv1.Job, timeout time.Duration) error {
+	test.Debugf("waiting for job %s to complete", job.Name)
+
+	if err := test.needsControllers("WaitForJobComplete"); err != nil {
+		return err
+	}
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		current, err := test.GetJob(job.Namespace, job.Name)
+		if err != nil {
+			return false, err
+		}
+		if cond := jobCondition(current, batchv1.JobFailed); cond != nil {
+			return false, &JobFailedError{job.Name, cond.Reason, cond.Message}
+		}
+		return jobCondition(current, batchv1.JobComplete) != nil, nil
+	})
+}
+
+// WaitForJobComplete waits until a job has successfully completed. The test
+// fails with the failure reason if the job fails.
+func (test *Test) WaitForJobComplete(job *batchv1.Job, timeout time.Duration) {
+	test.err(test.waitForJobComplete(job, timeout))
+}
+
+func (test *Test) waitForJobFailed(job *batchv1.Job, timeout time.Duration) (*JobFailedError, error) {
+	test.Debugf("waiting for job %s to fail", job.Name)
+
+	if err := test.needsControllers("WaitForJobFailed"); err != nil {
+		return nil, err
+	}
+
+	var failure *JobFailedError
+	err := wait.Poll(time.Second, timeout, func() (bool, error) {
+		current, err := test.GetJob(job.Namespace, job.Name)
+		if err != nil {
+			return false, err
+		}
+		if jobCondition(current, batchv1.JobComplete) != nil {
+			return false, fmt.Errorf("job %s completed successfully", job.Name)
+		}
+		if cond := jobCondition(current, batchv1.JobFailed); cond != nil {
+			failure = &JobFailedError{job.Name, cond.Reason, cond.Message}
+			return true, nil
+		}
+		return false, nil
+	})
+	return failure, err
+}
+
+// WaitForJobFailed waits until a job has failed and returns why it has failed,
+// eg. the BackoffLimitExceeded or DeadlineExceeded reasons. The test fails if
+// the job completes successfully.
+//
+// With the OnFailure restart policy, the failing containers of the job are
+// restarted in place, and those restarts fail the test when it's closed. Call
+// AllowPodRestarts in tests expecting such a job to fail.
+func (test *Test) WaitForJobFailed(job *batchv1.Job, timeout time.Duration) *JobFailedError {
+	failure, err := test.waitForJobFailed(job, timeout)
+	test.err(err)
+	return failure
+}
+
+func (test *Test) listPodsFromJob(job *batchv1.Job) (*v1.PodList, error) {
+	// The selector is generated by the API server.
+	current, err := test.GetJob(job.Namespace, job.Name)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := selectorToString(current.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	return test.listPods(job.Namespace, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+}
+
+// ListPodsFromJob returns the list of pods created by a job.
+func (test *Test) ListPodsFromJob(job *batchv1.Job) *v1.PodList {
+	pl, err := test.listPodsFromJob(job)
+	test.err(err)
+	return pl
+}
+
+func (test *Test) jobLogs(w io.Writer, job *batchv1.Job) error {
+	pl, err := test.listPodsFromJob(job)
+	if err != nil {
+		return err
+	}
+
+	for i := range pl.Items {
+		pod := &pl.Items[i]
+		for _, c := range pod.Spec.Containers {
+			fmt.Fprintf(w, "\n=== logs, pod=%s, container=%s\n\n", pod.Name, c.Name)
+			if err := test.PodLogs(w, pod, c.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// JobLogs writes the logs of all the containers of all the pods of a job on w.
+func (test *Test) JobLogs(w io.Writer, job *batchv1.Job) {
+	test.err(test.jobLogs(w, job))
+}
@@ -0,0 +1,35 @@
+package harness
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestJobCondition(t *testing.T) {
+	job := &batchv1.Job{
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobComplete, Status: v1.ConditionFalse},
+				{Type: batchv1.JobFailed, Status: v1.ConditionTrue, Reason: "BackoffLimitExceeded"},
+			},
+		},
+	}
+
+	assert.Nil(t, jobCondition(job, batchv1.JobComplete))
+	cond := jobCondition(job, batchv1.JobFailed)
+	assert.NotNil(t, cond)
+	assert.Equal(t, "BackoffLimitExceeded", cond.Reason)
+}
+
+func TestManualJobName(t *testing.T) {
+	name := manualJobName("nightly")
+	assert.Regexp(t, "^nightly-manual-[a-z0-9]{5}$", name)
+	assert.NotEqual(t, name, manualJobName("nightly"))
+
+	long := manualJobName(strings.Repeat("a", 80))
+	assert.Len(t, long, 63)
+}
@@ -0,0 +1,307 @@
+package harness
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// MetricSample is a single sample of a Prometheus metric.
+type MetricSample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// MetricFamily is a group of samples sharing the same metric name. Histogram
+// and summary families also hold their _bucket, _sum and _count samples.
+type MetricFamily struct {
+	Name string
+	Help string
+	// Type is one of counter, gauge, histogram, summary or untyped.
+	Type    string
+	Samples []MetricSample
+}
+
+// Metrics are the metric families exposed by a Prometheus endpoint, indexed by
+// name.
+type Metrics map[string]*MetricFamily
+
+// matchLabels returns whether the sample has all the given labels.
+func (s *MetricSample) matchLabels(labels map[string]string) bool {
+	for k, v := range labels {
+		if s.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Metrics) samples(name string, labels map[string]string) []MetricSample {
+	var samples []MetricSample
+	for _, family := range m {
+		for _, s := range family.Samples {
+			if s.Name == name && s.matchLabels(labels) {
+				samples = append(samples, s)
+			}
+		}
+	}
+	return samples
+}
+
+// Sample returns the value of the first sample named name that has all the
+// given labels. Sample returns false if no such sample exists.
+func (m Metrics) Sample(name string, labels map[string]string) (float64, bool) {
+	samples := m.samples(name, labels)
+	if len(samples) == 0 {
+		return 0, false
+	}
+	return samples[0].Value, true
+}
+
+// Sum returns the sum of the values of the samples named name that have all
+// the given labels.
+func (m Metrics) Sum(name string, labels map[string]string) float64 {
+	sum := 0.
+	for _, s := range m.samples(name, labels) {
+		sum += s.Value
+	}
+	return sum
+}
+
+// MetricsDelta returns by how much the sum of the samples named name with all
+// the given labels has changed between two scrapes.
+func MetricsDelta(before, after Metrics, name string, labels map[string]string) float64 {
+	return after.Sum(name, labels) - before.Sum(name, labels)
+}
+
+// family returns the family a sample belongs to, creating an untyped family if
+// needed.
+func (m Metrics) family(sampleName string) *MetricFamily {
+	if f, ok := m[sampleName]; ok {
+		return f
+	}
+
+	suffixes := map[string][]string{
+		"_bucket": {"histogram"},
+		"_sum":    {"histogram", "summary"},
+		"_count":  {"histogram", "summary"},
+		"_total":  {"counter"},
+	}
+	for suffix, types := range suffixes {
+		if !strings.HasSuffix(sampleName, suffix) {
+			continue
+		}
+		if f, ok := m[strings.TrimSuffix(sampleName, suffix)]; ok {
+			for _, t := range types {
+				if f.Type == t {
+					return f
+				}
+			}
+		}
+	}
+
+	f := &MetricFamily{Name: sampleName, Type: "untyped"}
+	m[sampleName] = f
+	return f
+}
+
+func (m Metrics) declare(name string) *MetricFamily {
+	f, ok := m[name]
+	if !ok {
+		f = &MetricFamily{Name: name, Type: "untyped"}
+		m[name] = f
+	}
+	return f
+}
+
+var helpReplacer = strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+
+// parseLabels parses the {name="value",...} part of a sample, s starting just
+// after the opening brace. It returns the rest of the line.
+func parseLabels(s string) (map[string]string, string, error) {
+	labels := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return nil, "", fmt.Errorf("invalid label set")
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return nil, "", fmt.Errorf("label %s: value isn't quoted", name)
+		}
+
+		var value strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		if i == len(s) {
+			return nil, "", fmt.Errorf("label %s: unterminated value", name)
+		}
+		labels[name] = value.String()
+
+		s = strings.TrimLeft(s[i+1:], " \t")
+		s = strings.TrimPrefix(s, ",")
+	}
+}
+
+func parseSample(line string) (*MetricSample, error) {
+	sample := &MetricSample{}
+
+	end := strings.IndexAny(line, "{ \t")
+	if end < 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+	sample.Name = line[:end]
+	rest := line[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		sample.Labels, rest, err = parseLabels(rest[1:])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The value may be followed by a timestamp, which we ignore.
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+	sample.Value = value
+
+	return sample, nil
+}
+
+// ParseMetrics parses metrics in the Prometheus text exposition format.
+func ParseMetrics(r io.Reader) (Metrics, error) {
+	metrics := make(Metrics)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			fields := strings.SplitN(strings.TrimSpace(line[1:]), " ", 3)
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[0] {
+			case "HELP":
+				metrics.declare(fields[1]).Help = helpReplacer.Replace(fields[2])
+			case "TYPE":
+				metrics.declare(fields[1]).Type = strings.TrimSpace(fields[2])
+			}
+			continue
+		}
+
+		sample, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("metrics: line %d: %w", n, err)
+		}
+		f := metrics.family(sample.Name)
+		f.Samples = append(f.Samples, *sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("metrics: %w", err)
+	}
+
+	return metrics, nil
+}
+
+func (test *Test) scrapeMetrics(pod *v1.Pod, port, path string) (Metrics, error) {
+	if path == "" {
+		path = "/metrics"
+	}
+
+	resp, err := test.proxyDo(pod, &ProxyRequest{
+		Port: port,
+		Path: path,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("scraping pod %s failed: unexpected status %d", pod.Name, resp.StatusCode)
+	}
+
+	return ParseMetrics(bytes.NewReader(resp.Body))
+}
+
+// ScrapeMetrics fetches the Prometheus metrics exposed by pod through the API
+// server proxy. Port can be a port name or the port number. If path is "", it
+// defaults to /metrics.
+func (test *Test) ScrapeMetrics(pod *v1.Pod, port, path string) Metrics {
+	metrics, err := test.scrapeMetrics(pod, port, path)
+	test.err(err)
+	return metrics
+}
+
+func (test *Test) waitForMetric(pod *v1.Pod, port, path, name string, labels map[string]string, predicate func(float64) bool, timeout time.Duration) error {
+	test.Debugf("waiting for metric %s%v of pod %s", name, labels, pod.Name)
+
+	if err := test.needsControllers("WaitForMetric"); err != nil {
+		return err
+	}
+
+	last := "no sample"
+	err := wait.Poll(time.Second, timeout, func() (bool, error) {
+		metrics, err := test.scrapeMetrics(pod, port, path)
+		if err != nil {
+			test.Debugf("%v", err)
+			return false, nil
+		}
+
+		samples := metrics.samples(name, labels)
+		if len(samples) == 0 {
+			return false, nil
+		}
+		value := metrics.Sum(name, labels)
+		last = strconv.FormatFloat(value, 'g', -1, 64)
+		return predicate(value), nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for metric %s%v failed (last value: %s): %w", name, labels, last, err)
+	}
+	return nil
+}
+
+// WaitForMetric scrapes the metrics of pod until predicate returns true for
+// the sum of the samples named name with all the given labels.
+func (test *Test) WaitForMetric(pod *v1.Pod, port, path, name string, labels map[string]string, predicate func(float64) bool, timeout time.Duration) {
+	test.err(test.waitForMetric(pod, port, path, name, labels, predicate, timeout))
+}
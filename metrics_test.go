@@ -0,0 +1,84 @@
+package harness
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const exposition = `# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="post",code="400"}    3 1395066363000
+http_requests_total{method="get",code="200",} 12
+
+# A comment.
+# HELP request_duration_seconds Request latency.
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.1"} 24054
+request_duration_seconds_bucket{le="+Inf"} 144320
+request_duration_seconds_sum 53423
+request_duration_seconds_count 144320
+
+msdos_file_access_time_seconds{path="C:\\DIR\\FILE.TXT",error="Cannot find file:\n\"FILE.TXT\""} 1.458255915e9
+temperature NaN
+`
+
+func TestParseMetrics(t *testing.T) {
+	metrics, err := ParseMetrics(strings.NewReader(exposition))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(metrics))
+
+	requests := metrics["http_requests_total"]
+	assert.Equal(t, "counter", requests.Type)
+	assert.Equal(t, "The total number of HTTP requests.", requests.Help)
+	assert.Equal(t, 3, len(requests.Samples))
+
+	v, ok := metrics.Sample("http_requests_total", map[string]string{"method": "post", "code": "400"})
+	assert.True(t, ok)
+	assert.Equal(t, 3., v)
+	assert.Equal(t, 1030., metrics.Sum("http_requests_total", map[string]string{"method": "post"}))
+	assert.Equal(t, 1042., metrics.Sum("http_requests_total", nil))
+	_, ok = metrics.Sample("http_requests_total", map[string]string{"method": "put"})
+	assert.False(t, ok)
+
+	duration := metrics["request_duration_seconds"]
+	assert.Equal(t, "histogram", duration.Type)
+	assert.Equal(t, 4, len(duration.Samples))
+	v, ok = metrics.Sample("request_duration_seconds_bucket", map[string]string{"le": "+Inf"})
+	assert.True(t, ok)
+	assert.Equal(t, 144320., v)
+
+	access := metrics["msdos_file_access_time_seconds"]
+	assert.Equal(t, "untyped", access.Type)
+	assert.Equal(t, `C:\DIR\FILE.TXT`, access.Samples[0].Labels["path"])
+	assert.Equal(t, "Cannot find file:\n\"FILE.TXT\"", access.Samples[0].Labels["error"])
+
+	v, ok = metrics.Sample("temperature", nil)
+	assert.True(t, ok)
+	assert.True(t, math.IsNaN(v))
+}
+
+func TestParseMetricsErrors(t *testing.T) {
+	for _, in := range []string{
+		"foo",
+		"foo{bar=\"baz\" 1",
+		"foo{bar=baz} 1",
+		"foo bar",
+	} {
+		_, err := ParseMetrics(strings.NewReader(in))
+		assert.Error(t, err, in)
+	}
+}
+
+func TestMetricsDelta(t *testing.T) {
+	before, err := ParseMetrics(strings.NewReader(`requests{code="200"} 10`))
+	assert.NoError(t, err)
+	after, err := ParseMetrics(strings.NewReader(`requests{code="200"} 25`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 15., MetricsDelta(before, after, "requests", map[string]string{"code": "200"}))
+	assert.Equal(t, 0., MetricsDelta(before, after, "requests", map[string]string{"code": "500"}))
+}
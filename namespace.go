@@ -11,7 +11,7 @@ import (
 func (test *Test) createNamespace(name string) (*v1.Namespace, error) {
 	test.Debugf("creating namespace %s", name)
 
-	namespace, err := test.harness.kubeClient.CoreV1().Namespaces().Create(context.TODO(), &v1.Namespace{
+	namespace, err := test.kubeClient.CoreV1().Namespaces().Create(context.TODO(), &v1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
 		},
@@ -24,13 +24,16 @@ func (test *Test) createNamespace(name string) (*v1.Namespace, error) {
 
 // CreateNamespace creates a new namespace.
 func (test *Test) CreateNamespace(name string) {
-	_, err := test.createNamespace(name)
-	test.err(err)
+	if _, err := test.createNamespace(name); err != nil {
+		test.err(err)
+		return
+	}
 
 	test.addNamespace(name)
 
+	root := test.root()
 	test.addFinalizer(func() error {
-		if err := test.deleteNamespace(name); err != nil {
+		if err := root.deleteNamespace(name); err != nil {
 			return err
 		}
 		return nil
@@ -42,7 +45,7 @@ func (test *Test) deleteNamespace(name string) error {
 
 	test.removeNamespace(name)
 
-	return test.harness.kubeClient.CoreV1().Namespaces().Delete(context.TODO(), name, metav1.DeleteOptions{})
+	return test.kubeClient.CoreV1().Namespaces().Delete(context.TODO(), name, metav1.DeleteOptions{})
 }
 
 // DeleteNamespace deletes a Namespace.
@@ -53,7 +56,7 @@ func (test *Test) DeleteNamespace(name string) {
 
 // GetNamespace returns a Namespace object if it exists or error.
 func (test *Test) GetNamespace(name string) (*v1.Namespace, error) {
-	ns, err := test.harness.kubeClient.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+	ns, err := test.kubeClient.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,160 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func (test *Test) createNetworkPolicy(namespace string, policy *networkingv1.NetworkPolicy) error {
+	test.Debugf("creating network policy %s", policy.Name)
+
+	policy.Namespace = namespace
+	if _, err := test.kubeClient.NetworkingV1().NetworkPolicies(namespace).Create(context.TODO(), policy, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create network policy %s: %w", policy.Name, err)
+	}
+	return nil
+}
+
+// CreateNetworkPolicy creates a network policy in the given namespace.
+func (test *Test) CreateNetworkPolicy(namespace string, policy *networkingv1.NetworkPolicy) {
+	err := test.createNetworkPolicy(namespace, policy)
+	test.err(err)
+}
+
+func (test *Test) getNetworkPolicy(namespace, name string) (*networkingv1.NetworkPolicy, error) {
+	policy, err := test.kubeClient.NetworkingV1().NetworkPolicies(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network policy %s: %w", name, err)
+	}
+	return policy, nil
+}
+
+// GetNetworkPolicy retrieves a network policy with a given name and namespace.
+func (test *Test) GetNetworkPolicy(namespace, name string) *networkingv1.NetworkPolicy {
+	policy, err := test.getNetworkPolicy(namespace, name)
+	test.err(err)
+	return policy
+}
+
+func (test *Test) loadNetworkPolicy(manifestPath string) (*networkingv1.NetworkPolicy, error) {
+	manifest, err := test.harness.openManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	policy := networkingv1.NetworkPolicy{}
+	if err := yaml.NewYAMLOrJSONDecoder(manifest, 100).Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to decode network policy %s: %w", manifestPath, err)
+	}
+
+	return &policy, nil
+}
+
+// LoadNetworkPolicy loads a network policy from a YAML manifest. The path to
+// the manifest is relative to Harness.ManifestDirectory.
+func (test *Test) LoadNetworkPolicy(manifestPath string) *networkingv1.NetworkPolicy {
+	policy, err := test.loadNetworkPolicy(manifestPath)
+	test.err(err)
+	return policy
+}
+
+func (test *Test) createNetworkPolicyFromFile(namespace string, manifestPath string) (*networkingv1.NetworkPolicy, error) {
+	policy, err := test.loadNetworkPolicy(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	err = test.createNetworkPolicy(namespace, policy)
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// CreateNetworkPolicyFromFile creates a network policy from a manifest file in the given namespace.
+func (test *Test) CreateNetworkPolicyFromFile(namespace string, manifestPath string) *networkingv1.NetworkPolicy {
+	policy, err := test.createNetworkPolicyFromFile(namespace, manifestPath)
+	test.err(err)
+	return policy
+}
+
+func (test *Test) deleteNetworkPolicy(policy *networkingv1.NetworkPolicy) error {
+	test.Debugf("deleting network policy %s", policy.Name)
+
+	if err := test.kubeClient.NetworkingV1().NetworkPolicies(policy.Namespace).Delete(context.TODO(), policy.Name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("deleting network policy %v failed: %w", policy.Name, err)
+	}
+	return nil
+}
+
+// DeleteNetworkPolicy deletes a network policy.
+func (test *Test) DeleteNetworkPolicy(policy *networkingv1.NetworkPolicy) {
+	err := test.deleteNetworkPolicy(policy)
+	test.err(err)
+}
+
+func (test *Test) waitForNetworkPolicyDeleted(policy *networkingv1.NetworkPolicy) error {
+	test.Debugf("waiting for network policy %s to be deleted", policy.Name)
+
+	err := wait.Poll(time.Second, time.Minute, func() (bool, error) {
+		_, err := test.kubeClient.NetworkingV1().NetworkPolicies(policy.Namespace).Get(context.TODO(), policy.Name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for network policy to go away failed: %w", err)
+	}
+
+	return nil
+}
+
+// WaitForNetworkPolicyDeleted waits until a deleted network policy has disappeared from the cluster.
+func (test *Test) WaitForNetworkPolicyDeleted(policy *networkingv1.NetworkPolicy) {
+	test.err(test.waitForNetworkPolicyDeleted(policy))
+}
+
+// NewDefaultDenyNetworkPolicy returns a network policy selecting all the pods
+// of a namespace and denying all the traffic of the given policy types. If no
+// policy type is given, both ingress and egress traffic are denied.
+//
+// Denying egress traffic also denies DNS queries. Tests usually need to
+// explicitly allow them on top of the default deny policy.
+func NewDefaultDenyNetworkPolicy(namespace string, policyTypes ...networkingv1.PolicyType) *networkingv1.NetworkPolicy {
+	if len(policyTypes) == 0 {
+		policyTypes = []networkingv1.PolicyType{
+			networkingv1.PolicyTypeIngress,
+			networkingv1.PolicyTypeEgress,
+		}
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default-deny",
+			Namespace: namespace,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: policyTypes,
+		},
+	}
+}
+
+// CreateDefaultDenyNetworkPolicy locks down a namespace by creating a network
+// policy denying all the traffic of the given policy types to and from its
+// pods. If no policy type is given, both ingress and egress traffic are
+// denied.
+func (test *Test) CreateDefaultDenyNetworkPolicy(namespace string, policyTypes ...networkingv1.PolicyType) *networkingv1.NetworkPolicy {
+	policy := NewDefaultDenyNetworkPolicy(namespace, policyTypes...)
+	test.CreateNetworkPolicy(namespace, policy)
+	return policy
+}
@@ -0,0 +1,20 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func TestNewDefaultDenyNetworkPolicy(t *testing.T) {
+	policy := NewDefaultDenyNetworkPolicy("ns")
+	assert.Equal(t, "ns", policy.Namespace)
+	assert.Empty(t, policy.Spec.PodSelector.MatchLabels)
+	assert.Empty(t, policy.Spec.Ingress)
+	assert.Empty(t, policy.Spec.Egress)
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}, policy.Spec.PolicyTypes)
+
+	policy = NewDefaultDenyNetworkPolicy("ns", networkingv1.PolicyTypeIngress)
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, policy.Spec.PolicyTypes)
+}
@@ -5,13 +5,18 @@ import (
 	"fmt"
 	"time"
 
+	"golang.org/x/sync/errgroup"
 	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 func (test *Test) listNodes(options metav1.ListOptions) (*v1.NodeList, error) {
-	return test.harness.kubeClient.CoreV1().Nodes().List(context.TODO(), options)
+	return test.kubeClient.CoreV1().Nodes().List(context.TODO(), options)
 }
 
 // ListNodes returns all nodes that are part of the cluster.
@@ -48,6 +53,10 @@ func (test *Test) NodeReady(node *v1.Node) bool {
 func (test *Test) waitForNodesReady(expectedNodes int, exact bool, timeout time.Duration) error {
 	test.Debugf("waiting for %d nodes to be ready", expectedNodes)
 
+	if err := test.needsControllers("WaitForNodesReady"); err != nil {
+		return err
+	}
+
 	numReady := 0
 
 	return wait.Poll(time.Second, timeout, func() (bool, error) {
@@ -94,3 +103,264 @@ func (test *Test) WaitForNodesReadyMin(minExpectedNodes int, timeout time.Durati
 	err := test.waitForNodesReady(minExpectedNodes, false, timeout)
 	test.err(err)
 }
+
+// GetNode returns a Node object if it exists or error.
+func (test *Test) GetNode(name string) (*v1.Node, error) {
+	node, err := test.kubeClient.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// mutateNode applies mutate to node and registers a teardown calling restore
+// with the node state from before the mutation. Node changes are restored when
+// the test is closed, even if it has failed, as a node left tainted or
+// unschedulable would impact the other tests running on the cluster.
+func (test *Test) mutateNode(node *v1.Node, mutate func(n *v1.Node), restore func(n, original *v1.Node)) error {
+	var original *v1.Node
+	err := test.update(node, func(o runtime.Object) {
+		n := o.(*v1.Node)
+		original = n.DeepCopy()
+		mutate(n)
+	})
+	if err != nil {
+		return err
+	}
+
+	name := node.Name
+	root := test.root()
+	test.addTeardown(func() error {
+		root.Debugf("restoring node %s", name)
+		n := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		return root.update(n, func(o runtime.Object) {
+			restore(o.(*v1.Node), original)
+		})
+	})
+	return nil
+}
+
+func restoreUnschedulable(n, original *v1.Node) {
+	n.Spec.Unschedulable = original.Spec.Unschedulable
+}
+
+func (test *Test) cordonNode(node *v1.Node) error {
+	test.Debugf("cordoning node %s", node.Name)
+
+	return test.mutateNode(node, func(n *v1.Node) {
+		n.Spec.Unschedulable = true
+	}, restoreUnschedulable)
+}
+
+// CordonNode marks a node as unschedulable. The node is made schedulable again,
+// if it was, when the test is closed.
+func (test *Test) CordonNode(node *v1.Node) {
+	test.err(test.cordonNode(node))
+}
+
+func (test *Test) uncordonNode(node *v1.Node) error {
+	test.Debugf("uncordoning node %s", node.Name)
+
+	return test.mutateNode(node, func(n *v1.Node) {
+		n.Spec.Unschedulable = false
+	}, restoreUnschedulable)
+}
+
+// UncordonNode marks a node as schedulable. The node is made unschedulable
+// again, if it was, when the test is closed.
+func (test *Test) UncordonNode(node *v1.Node) {
+	test.err(test.uncordonNode(node))
+}
+
+// findTaint returns the index of the taint with the key and effect of taint,
+// or -1.
+func findTaint(taints []v1.Taint, taint *v1.Taint) int {
+	for i := range taints {
+		if taints[i].MatchTaint(taint) {
+			return i
+		}
+	}
+	return -1
+}
+
+// setTaint adds or replaces taint.
+func setTaint(taints []v1.Taint, taint *v1.Taint) []v1.Taint {
+	if i := findTaint(taints, taint); i >= 0 {
+		taints[i] = *taint
+		return taints
+	}
+	return append(taints, *taint)
+}
+
+// removeTaint removes the taint with the key and effect of taint.
+func removeTaint(taints []v1.Taint, taint *v1.Taint) []v1.Taint {
+	if i := findTaint(taints, taint); i >= 0 {
+		return append(taints[:i], taints[i+1:]...)
+	}
+	return taints
+}
+
+// restoreTaint puts back the taint with the key and effect of taint as it was
+// in original.
+func restoreTaint(taint *v1.Taint) func(n, original *v1.Node) {
+	return func(n, original *v1.Node) {
+		if i := findTaint(original.Spec.Taints, taint); i >= 0 {
+			n.Spec.Taints = setTaint(n.Spec.Taints, &original.Spec.Taints[i])
+			return
+		}
+		n.Spec.Taints = removeTaint(n.Spec.Taints, taint)
+	}
+}
+
+func (test *Test) addNodeTaint(node *v1.Node, taint v1.Taint) error {
+	test.Debugf("adding taint %s to node %s", taint.ToString(), node.Name)
+
+	return test.mutateNode(node, func(n *v1.Node) {
+		n.Spec.Taints = setTaint(n.Spec.Taints, &taint)
+	}, restoreTaint(&taint))
+}
+
+// AddNodeTaint adds a taint to a node, replacing the taint with the same key and
+// effect if there's one. The node taints are restored when the test is closed.
+func (test *Test) AddNodeTaint(node *v1.Node, taint v1.Taint) {
+	test.err(test.addNodeTaint(node, taint))
+}
+
+func (test *Test) removeNodeTaint(node *v1.Node, taint v1.Taint) error {
+	test.Debugf("removing taint %s from node %s", taint.ToString(), node.Name)
+
+	return test.mutateNode(node, func(n *v1.Node) {
+		n.Spec.Taints = removeTaint(n.Spec.Taints, &taint)
+	}, restoreTaint(&taint))
+}
+
+// RemoveNodeTaint removes the taint with the key and effect of taint from a
+// node. The node taints are restored when the test is closed.
+func (test *Test) RemoveNodeTaint(node *v1.Node, taint v1.Taint) {
+	test.err(test.removeNodeTaint(node, taint))
+}
+
+// restoreLabels puts back the given labels as they were in original.
+func restoreLabels(labels map[string]string) func(n, original *v1.Node) {
+	return func(n, original *v1.Node) {
+		for key := range labels {
+			if value, ok := original.Labels[key]; ok {
+				if n.Labels == nil {
+					n.Labels = make(map[string]string)
+				}
+				n.Labels[key] = value
+				continue
+			}
+			delete(n.Labels, key)
+		}
+	}
+}
+
+func (test *Test) addNodeLabels(node *v1.Node, labels map[string]string) error {
+	test.Debugf("adding labels %v to node %s", labels, node.Name)
+
+	return test.mutateNode(node, func(n *v1.Node) {
+		if n.Labels == nil {
+			n.Labels = make(map[string]string)
+		}
+		for key, value := range labels {
+			n.Labels[key] = value
+		}
+	}, restoreLabels(labels))
+}
+
+// AddNodeLabels adds labels to a node, overwriting existing labels with the
+// same keys. The node labels are restored when the test is closed.
+func (test *Test) AddNodeLabels(node *v1.Node, labels map[string]string) {
+	test.err(test.addNodeLabels(node, labels))
+}
+
+// drainablePods returns the pods a drain evicts: DaemonSet pods, which would be
+// recreated on the node, mirror pods and completed pods are skipped.
+func drainablePods(pods []v1.Pod) []v1.Pod {
+	drainable := []v1.Pod{}
+	for _, pod := range pods {
+		if _, ok := pod.Annotations[v1.MirrorPodAnnotationKey]; ok {
+			continue
+		}
+		if controller := metav1.GetControllerOf(&pod); controller != nil && controller.Kind == "DaemonSet" {
+			continue
+		}
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		drainable = append(drainable, pod)
+	}
+	return drainable
+}
+
+// evictPod evicts pod, retrying while the eviction is refused because of a
+// pod disruption budget.
+func (test *Test) evictPod(pod *v1.Pod, timeout time.Duration) error {
+	test.Debugf("evicting pod %s/%s", pod.Namespace, pod.Name)
+
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	}
+	err := wait.Poll(time.Second, timeout, func() (bool, error) {
+		err := test.kubeClient.PolicyV1beta1().Evictions(pod.Namespace).Evict(context.TODO(), eviction)
+		switch {
+		case err == nil, apierrors.IsNotFound(err):
+			return true, nil
+		case apierrors.IsTooManyRequests(err):
+			test.Debugf("eviction of pod %s refused, retrying: %v", pod.Name, err)
+			return false, nil
+		default:
+			return false, err
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("evicting pod %s/%s failed: %w", pod.Namespace, pod.Name, err)
+	}
+	return nil
+}
+
+func (test *Test) drainNode(node *v1.Node, timeout time.Duration) error {
+	test.Debugf("draining node %s", node.Name)
+
+	if err := test.needsControllers("DrainNode"); err != nil {
+		return err
+	}
+
+	if err := test.cordonNode(node); err != nil {
+		return err
+	}
+
+	pl, err := test.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", node.Name).String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	pods := drainablePods(pl.Items)
+	var eg errgroup.Group
+	for i := range pods {
+		pod := &pods[i]
+		eg.Go(func() error {
+			if err := test.evictPod(pod, timeout); err != nil {
+				return err
+			}
+			return test.waitForPodDeleted(pod, timeout)
+		})
+	}
+	return eg.Wait()
+}
+
+// DrainNode cordons a node and evicts its pods, like kubectl drain
+// --ignore-daemonsets does. Evictions go through the eviction API and so
+// respect pod disruption budgets: evictions refused because of a budget are
+// retried until timeout. DrainNode returns once the evicted pods are gone. The
+// node is uncordoned when the test is closed, evicted pods are not restored.
+func (test *Test) DrainNode(node *v1.Node, timeout time.Duration) {
+	test.err(test.drainNode(node, timeout))
+}
@@ -0,0 +1,73 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTaints(t *testing.T) {
+	noSchedule := v1.Taint{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule}
+	noExecute := v1.Taint{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoExecute}
+
+	taints := setTaint(nil, &noSchedule)
+	assert.Equal(t, []v1.Taint{noSchedule}, taints)
+
+	replaced := noSchedule
+	replaced.Value = "tpu"
+	taints = setTaint(taints, &replaced)
+	assert.Equal(t, []v1.Taint{replaced}, taints)
+
+	taints = setTaint(taints, &noExecute)
+	assert.Len(t, taints, 2)
+
+	taints = removeTaint(taints, &noSchedule)
+	assert.Equal(t, []v1.Taint{noExecute}, taints)
+	assert.Equal(t, -1, findTaint(taints, &noSchedule))
+}
+
+func TestRestoreTaint(t *testing.T) {
+	taint := v1.Taint{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule}
+
+	// Added taint: restoring removes it.
+	original := &v1.Node{}
+	node := &v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{taint}}}
+	restoreTaint(&taint)(node, original)
+	assert.Empty(t, node.Spec.Taints)
+
+	// Removed or modified taint: restoring puts the original one back.
+	original = &v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{taint}}}
+	node = &v1.Node{}
+	restoreTaint(&taint)(node, original)
+	assert.Equal(t, []v1.Taint{taint}, node.Spec.Taints)
+}
+
+func TestRestoreLabels(t *testing.T) {
+	original := &v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"zone": "a"}}}
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"zone": "b", "role": "test", "other": "x"}}}
+
+	restoreLabels(map[string]string{"zone": "b", "role": "test"})(node, original)
+	assert.Equal(t, map[string]string{"zone": "a", "other": "x"}, node.Labels)
+}
+
+func TestDrainablePods(t *testing.T) {
+	newPod := func(name string) v1.Pod {
+		return v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	controller := true
+
+	regular := newPod("regular")
+	daemon := newPod("daemon")
+	daemon.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "agent", Controller: &controller}}
+	replica := newPod("replica")
+	replica.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web", Controller: &controller}}
+	mirror := newPod("mirror")
+	mirror.Annotations = map[string]string{v1.MirrorPodAnnotationKey: "hash"}
+	completed := newPod("completed")
+	completed.Status.Phase = v1.PodSucceeded
+
+	pods := drainablePods([]v1.Pod{regular, daemon, replica, mirror, completed})
+	assert.Equal(t, []v1.Pod{regular, replica}, pods)
+}
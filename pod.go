@@ -2,20 +2,48 @@ package harness
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
+	"reflect"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
 )
 
+func (test *Test) createPod(namespace string, pod *v1.Pod) error {
+	test.Debugf("creating pod %s", pod.Name)
+
+	pod.Namespace = namespace
+	if _, err := test.kubeClient.CoreV1().Pods(namespace).Create(context.TODO(), pod, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create pod %s: %w", pod.Name, err)
+	}
+	return nil
+}
+
+// CreatePod creates a pod in the given namespace.
+func (test *Test) CreatePod(namespace string, pod *v1.Pod) {
+	err := test.createPod(namespace, pod)
+	test.err(err)
+}
+
+// GetPod returns a Pod object if it exists or error.
+func (test *Test) GetPod(ns, name string) (*v1.Pod, error) {
+	pod, err := test.kubeClient.CoreV1().Pods(ns).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return pod, nil
+}
+
 func (test *Test) listPods(namespace string, options metav1.ListOptions) (*v1.PodList, error) {
-	return test.harness.kubeClient.CoreV1().Pods(namespace).List(context.TODO(), options)
+	return test.kubeClient.CoreV1().Pods(namespace).List(context.TODO(), options)
 }
 
 // ListPods returns the list of pods in namespace matching options.
@@ -43,12 +71,34 @@ func (test *Test) ListPodsFromDeployment(d *appsv1.Deployment) *v1.PodList {
 	return pl
 }
 
+func (test *Test) listPodsFromDaemonSet(d *appsv1.DaemonSet) (*v1.PodList, error) {
+	selector, err := selectorToString(d.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	return test.listPods(d.Namespace, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+}
+
+// ListPodsFromDaemonSet returns the list of pods created by a daemonset.
+func (test *Test) ListPodsFromDaemonSet(d *appsv1.DaemonSet) *v1.PodList {
+	pl, err := test.listPodsFromDaemonSet(d)
+	test.err(err)
+	return pl
+}
+
 // PodReady returns whether a pod is running and each container has is in the
-// ready state.
+// ready state. Pods that have run to completion successfully, eg. pods created
+// by jobs, aren't ready. Failed pods are never going to be ready and an error
+// is returned for them. Pods created by jobs should be waited for with
+// WaitForJobComplete instead.
 func (test *Test) PodReady(pod v1.Pod) (bool, error) {
 	switch pod.Status.Phase {
-	case v1.PodFailed, v1.PodSucceeded:
-		return false, fmt.Errorf("pod completed")
+	case v1.PodSucceeded:
+		return false, nil
+	case v1.PodFailed:
+		return false, fmt.Errorf("pod %s failed", pod.Name)
 	case v1.PodRunning:
 		for _, cond := range pod.Status.Conditions {
 			if cond.Type != v1.PodReady {
@@ -64,8 +114,12 @@ func (test *Test) PodReady(pod v1.Pod) (bool, error) {
 // WaitForPodsReady waits for a selection of Pods to be running and each
 // container to pass its readiness check.
 func (test *Test) WaitForPodsReady(namespace string, opts metav1.ListOptions, expectedReplicas int, timeout time.Duration) error {
+	if err := test.needsControllers("WaitForPodsReady"); err != nil {
+		return err
+	}
+
 	return wait.Poll(time.Second, timeout, func() (bool, error) {
-		pl, err := test.harness.kubeClient.CoreV1().Pods(namespace).List(context.TODO(), opts)
+		pl, err := test.kubeClient.CoreV1().Pods(namespace).List(context.TODO(), opts)
 		if err != nil {
 			return false, err
 		}
@@ -89,17 +143,59 @@ func (test *Test) WaitForPodsReady(namespace string, opts metav1.ListOptions, ex
 	})
 }
 
+// podContainerName returns containerName if given, or the name of the pod's
+// only container.
+func podContainerName(pod *v1.Pod, containerName string) (string, error) {
+	if containerName != "" {
+		return containerName, nil
+	}
+	if len(pod.Spec.Containers) != 1 {
+		return "", fmt.Errorf("no container name specified and found %d containers", len(pod.Spec.Containers))
+	}
+	return pod.Spec.Containers[0].Name, nil
+}
+
+// waitForPodReady waits until a pod is running and ready and returns its
+// latest version.
+func (test *Test) waitForPodReady(pod *v1.Pod, timeout time.Duration) (*v1.Pod, error) {
+	test.Debugf("waiting for pod %s to be ready", pod.Name)
+
+	if err := test.needsControllers("WaitForPodReady"); err != nil {
+		return nil, err
+	}
+
+	var current *v1.Pod
+	err := wait.Poll(time.Second, timeout, func() (bool, error) {
+		var err error
+		current, err = test.GetPod(pod.Namespace, pod.Name)
+		if err != nil {
+			return false, err
+		}
+		return test.PodReady(*current)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("waiting for pod %s to be ready failed: %w", pod.Name, err)
+	}
+	return current, nil
+}
+
+// WaitForPodReady waits until a pod is running and ready and returns its
+// latest version, which includes the pod IP address.
+func (test *Test) WaitForPodReady(pod *v1.Pod, timeout time.Duration) *v1.Pod {
+	current, err := test.waitForPodReady(pod, timeout)
+	test.err(err)
+	return current
+}
+
 // PodLogs writes the container logs on w. If the pod has a single container,
 // containerName is optional and can be set to "".
 func (test *Test) PodLogs(w io.Writer, pod *v1.Pod, containerName string) error {
-	if containerName == "" {
-		if len(pod.Spec.Containers) != 1 {
-			return fmt.Errorf("logs: no container name specified and found %d containers", len(pod.Spec.Containers))
-		}
-		containerName = pod.Spec.Containers[0].Name
+	containerName, err := podContainerName(pod, containerName)
+	if err != nil {
+		return fmt.Errorf("logs: %w", err)
 	}
 
-	logs, err := test.harness.kubeClient.CoreV1().RESTClient().Get().
+	logs, err := test.kubeClient.CoreV1().RESTClient().Get().
 		Resource("pods").
 		Namespace(pod.Namespace).
 		Name(pod.Name).SubResource("log").
@@ -118,38 +214,32 @@ func (test *Test) PodLogs(w io.Writer, pod *v1.Pod, containerName string) error
 //
 // If port is "", the first port found in the containers spec will be used.
 func (test *Test) PodProxyGet(pod *v1.Pod, port, path string) *rest.Request {
-	name := pod.Name
-	if port == "" {
-		name += ":" + port
-	}
-
-	return test.harness.kubeClient.
+	return test.kubeClient.
 		CoreV1().
 		RESTClient().
 		Get().
 		Namespace(pod.Namespace).
 		Resource("pods").
-		Name(name).
+		Name(proxyName(pod.Name, &ProxyRequest{Port: port})).
 		Suffix("proxy" + path)
 }
 
-func (test *Test) podProxyGetJSON(pod *v1.Pod, port, path string, v interface{}) error {
-	data, err := test.PodProxyGet(pod, port, path).DoRaw(context.TODO())
-	if err != nil {
-		return err
-	}
-	return json.Unmarshal(data, v)
-}
-
-// PodProxyGetJSON is a convenience function around PodProxyGet that also
-// unmarshals the response body into v.
+// PodProxyGetJSON performs an HTTP GET to a pod through the API server proxy
+// and unmarshals the response body into v. Port can be a port name or the port
+// number.
+//
+// If port is "", the first port found in the containers spec will be used.
 func (test *Test) PodProxyGetJSON(pod *v1.Pod, port, path string, v interface{}) {
-	test.err(test.podProxyGetJSON(pod, port, path, v))
+	test.err(test.proxyGetJSON(pod, port, path, v))
 }
 
 // deletePod deletes a pod in the given namespace.
 func (test *Test) deletePod(pod *v1.Pod) error {
-	if err := test.harness.kubeClient.CoreV1().Pods(pod.Namespace).Delete(context.TODO(), pod.Name, metav1.DeleteOptions{}); err != nil {
+	return test.deletePodWithOptions(pod, metav1.DeleteOptions{})
+}
+
+func (test *Test) deletePodWithOptions(pod *v1.Pod, options metav1.DeleteOptions) error {
+	if err := test.kubeClient.CoreV1().Pods(pod.Namespace).Delete(context.TODO(), pod.Name, options); err != nil {
 		return fmt.Errorf("deleting pod %v failed: %w", pod.Name, err)
 	}
 	return nil
@@ -159,3 +249,186 @@ func (test *Test) deletePod(pod *v1.Pod) error {
 func (test *Test) DeletePod(pod *v1.Pod) {
 	test.err(test.deletePod(pod))
 }
+
+// waitForPodDeleted waits until a deleted pod has disappeared from the cluster.
+func (test *Test) waitForPodDeleted(pod *v1.Pod, timeout time.Duration) error {
+	test.Debugf("waiting for pod %s to be deleted", pod.Name)
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		current, err := test.GetPod(pod.Namespace, pod.Name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+
+			return false, err
+		}
+
+		// A controller may have recreated a pod with the same name.
+		return pod.UID != "" && current.UID != pod.UID, nil
+	})
+}
+
+// WaitForPodDeleted waits until a deleted pod has disappeared from the cluster.
+// A pod recreated with the same name, eg. by a StatefulSet, doesn't count as
+// the deleted pod.
+func (test *Test) WaitForPodDeleted(pod *v1.Pod, timeout time.Duration) {
+	test.err(test.waitForPodDeleted(pod, timeout))
+}
+
+// containerRestart describes a container that has restarted.
+type containerRestart struct {
+	pod       string
+	container string
+	restarts  int32
+	// Last termination state, if known.
+	reason   string
+	exitCode int32
+}
+
+func (r *containerRestart) String() string {
+	s := fmt.Sprintf("pod %s, container %s restarted %d time(s)", r.pod, r.container, r.restarts)
+	if r.reason != "" {
+		s += fmt.Sprintf(" (last termination: %s, exit code %d)", r.reason, r.exitCode)
+	}
+	return s
+}
+
+func podContainerStatuses(pod *v1.Pod) []v1.ContainerStatus {
+	statuses := make([]v1.ContainerStatus, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses))
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	return append(statuses, pod.Status.ContainerStatuses...)
+}
+
+// podRestarts returns the list of containers of pod that have restarted.
+func podRestarts(pod *v1.Pod) []containerRestart {
+	var restarts []containerRestart
+	for _, cs := range podContainerStatuses(pod) {
+		if cs.RestartCount == 0 {
+			continue
+		}
+		r := containerRestart{
+			pod:       pod.Name,
+			container: cs.Name,
+			restarts:  cs.RestartCount,
+		}
+		if term := cs.LastTerminationState.Terminated; term != nil {
+			r.reason = term.Reason
+			r.exitCode = term.ExitCode
+		}
+		restarts = append(restarts, r)
+	}
+	return restarts
+}
+
+// podOOMKills returns the list of containers of pod that have been OOM killed.
+func podOOMKills(pod *v1.Pod) []string {
+	var containers []string
+	for _, cs := range podContainerStatuses(pod) {
+		for _, term := range []*v1.ContainerStateTerminated{cs.State.Terminated, cs.LastTerminationState.Terminated} {
+			if term != nil && term.Reason == "OOMKilled" {
+				containers = append(containers, cs.Name)
+				break
+			}
+		}
+	}
+	return containers
+}
+
+func (test *Test) assertNoRestarts(namespace string, opts metav1.ListOptions) error {
+	pl, err := test.listPods(namespace, opts)
+	if err != nil {
+		return err
+	}
+
+	var msgs []string
+	for i := range pl.Items {
+		for _, r := range podRestarts(&pl.Items[i]) {
+			msgs = append(msgs, r.String())
+		}
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("pods have restarted:\n%s", strings.Join(msgs, "\n"))
+	}
+	return nil
+}
+
+// AssertNoRestarts checks that none of the containers of a selection of Pods
+// has restarted.
+func (test *Test) AssertNoRestarts(namespace string, opts metav1.ListOptions) {
+	test.err(test.assertNoRestarts(namespace, opts))
+}
+
+func (test *Test) assertNoOOMKills(namespace string, opts metav1.ListOptions) error {
+	pl, err := test.listPods(namespace, opts)
+	if err != nil {
+		return err
+	}
+
+	var msgs []string
+	for i := range pl.Items {
+		pod := &pl.Items[i]
+		for _, c := range podOOMKills(pod) {
+			msgs = append(msgs, fmt.Sprintf("pod %s, container %s", pod.Name, c))
+		}
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("containers have been OOM killed:\n%s", strings.Join(msgs, "\n"))
+	}
+	return nil
+}
+
+// AssertNoOOMKills checks that none of the containers of a selection of Pods
+// has been killed for exceeding its memory limit.
+func (test *Test) AssertNoOOMKills(namespace string, opts metav1.ListOptions) {
+	test.err(test.assertNoOOMKills(namespace, opts))
+}
+
+func (test *Test) waitForStablePods(namespace string, opts metav1.ListOptions, window, timeout time.Duration) error {
+	test.Debugf("waiting for pods to be stable for %v", window)
+
+	if err := test.needsControllers("WaitForStablePods"); err != nil {
+		return err
+	}
+
+	var stableSince time.Time
+	var restarts map[string]int32
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		pl, err := test.listPods(namespace, opts)
+		if err != nil {
+			return false, err
+		}
+
+		stable := len(pl.Items) > 0
+		current := make(map[string]int32)
+		for _, pod := range pl.Items {
+			if ready, err := test.PodReady(pod); err != nil || !ready {
+				stable = false
+			}
+			for _, cs := range podContainerStatuses(&pod) {
+				current[pod.Name+"/"+cs.Name] = cs.RestartCount
+			}
+		}
+		if !reflect.DeepEqual(current, restarts) {
+			stable = false
+		}
+		restarts = current
+
+		if !stable {
+			stableSince = time.Time{}
+			return false, nil
+		}
+		if stableSince.IsZero() {
+			stableSince = time.Now()
+		}
+		return time.Since(stableSince) >= window, nil
+	})
+}
+
+// WaitForStablePods waits until a selection of Pods has been running and ready
+// for at least window, without any pod being added or removed nor any
+// container restarting.
+func (test *Test) WaitForStablePods(namespace string, opts metav1.ListOptions, window, timeout time.Duration) {
+	test.err(test.waitForStablePods(namespace, opts, window, timeout))
+}
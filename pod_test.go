@@ -0,0 +1,64 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodRestartsAndOOMKills(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app"},
+		Status: v1.PodStatus{
+			InitContainerStatuses: []v1.ContainerStatus{
+				{Name: "init"},
+			},
+			ContainerStatuses: []v1.ContainerStatus{
+				{Name: "sidecar"},
+				{
+					Name:         "main",
+					RestartCount: 2,
+					LastTerminationState: v1.ContainerState{
+						Terminated: &v1.ContainerStateTerminated{
+							Reason:   "OOMKilled",
+							ExitCode: 137,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	restarts := podRestarts(pod)
+	assert.Equal(t, 1, len(restarts))
+	assert.Equal(t, "pod app, container main restarted 2 time(s) (last termination: OOMKilled, exit code 137)", restarts[0].String())
+
+	assert.Equal(t, []string{"main"}, podOOMKills(pod))
+}
+
+func TestPodReady(t *testing.T) {
+	test := &Test{}
+	ready := v1.PodCondition{Type: v1.PodReady, Status: v1.ConditionTrue}
+
+	tests := []struct {
+		name    string
+		status  v1.PodStatus
+		ready   bool
+		wantErr bool
+	}{
+		{"pending", v1.PodStatus{Phase: v1.PodPending}, false, false},
+		{"running", v1.PodStatus{Phase: v1.PodRunning, Conditions: []v1.PodCondition{ready}}, true, false},
+		{"succeeded", v1.PodStatus{Phase: v1.PodSucceeded}, false, false},
+		{"failed", v1.PodStatus{Phase: v1.PodFailed}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := test.PodReady(v1.Pod{Status: tt.status})
+			assert.Equal(t, tt.ready, ok)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
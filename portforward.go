@@ -0,0 +1,171 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+
+	"github.com/dlespiau/kube-test-harness/logger"
+)
+
+// debugWriter is an io.Writer sending what's written to it to the test debug
+// logs.
+type debugWriter struct {
+	test   *Test
+	prefix string
+}
+
+func (w *debugWriter) Write(p []byte) (int, error) {
+	w.test.logger.Logf(logger.Debug, "%s: %s", w.prefix, strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
+
+func (test *Test) portForwardPod(pod *v1.Pod, remotePort int) (string, error) {
+	test.Debugf("forwarding port %d of pod %s", remotePort, pod.Name)
+
+	if err := test.needsControllers("PortForward"); err != nil {
+		return "", err
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(test.restConfig)
+	if err != nil {
+		return "", fmt.Errorf("port-forward: %w", err)
+	}
+
+	url := test.kubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	out := &debugWriter{test, "port-forward " + pod.Name}
+	fw, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", remotePort)}, stopCh, readyCh, out, out)
+	if err != nil {
+		return "", fmt.Errorf("port-forward: %w", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fw.ForwardPorts()
+	}()
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return "", fmt.Errorf("failed to forward port %d of pod %s: %w", remotePort, pod.Name, err)
+	}
+
+	ports, err := fw.GetPorts()
+	if err != nil {
+		close(stopCh)
+		return "", fmt.Errorf("port-forward: %w", err)
+	}
+
+	test.addTeardown(func() error {
+		test.Debugf("stopping port forward to pod %s", pod.Name)
+		close(stopCh)
+		return nil
+	})
+
+	return fmt.Sprintf("127.0.0.1:%d", ports[0].Local), nil
+}
+
+// containerPort resolves a port, potentially named, to a container port number.
+func containerPort(pod *v1.Pod, port intstr.IntOrString) (int, error) {
+	if port.Type == intstr.Int {
+		return port.IntValue(), nil
+	}
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Name == port.StrVal {
+				return int(p.ContainerPort), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("pod %s has no port named %s", pod.Name, port.StrVal)
+}
+
+// serviceTarget returns a pod backing service as well as the port on that pod
+// the service port servicePort is routed to.
+func (test *Test) serviceTarget(service *v1.Service, servicePort int) (*v1.Pod, int, error) {
+	var sp *v1.ServicePort
+	for i := range service.Spec.Ports {
+		if int(service.Spec.Ports[i].Port) == servicePort {
+			sp = &service.Spec.Ports[i]
+			break
+		}
+	}
+	if sp == nil {
+		return nil, 0, fmt.Errorf("service %s has no port %d", service.Name, servicePort)
+	}
+
+	endpoints, err := test.getEndpoints(service.Namespace, service.Name)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.TargetRef == nil || address.TargetRef.Kind != "Pod" {
+				continue
+			}
+			pod, err := test.kubeClient.CoreV1().Pods(service.Namespace).Get(context.TODO(), address.TargetRef.Name, metav1.GetOptions{})
+			if err != nil {
+				return nil, 0, err
+			}
+
+			targetPort := sp.TargetPort
+			if targetPort.Type == intstr.Int && targetPort.IntVal == 0 {
+				targetPort = intstr.FromInt(int(sp.Port))
+			}
+			port, err := containerPort(pod, targetPort)
+			if err != nil {
+				return nil, 0, err
+			}
+			return pod, port, nil
+		}
+	}
+
+	return nil, 0, fmt.Errorf("service %s has no ready pod", service.Name)
+}
+
+func (test *Test) portForward(target runtime.Object, remotePort int) (string, error) {
+	switch o := target.(type) {
+	case *v1.Pod:
+		return test.portForwardPod(o, remotePort)
+	case *v1.Service:
+		pod, port, err := test.serviceTarget(o, remotePort)
+		if err != nil {
+			return "", fmt.Errorf("port-forward: %w", err)
+		}
+		return test.portForwardPod(pod, port)
+	default:
+		return "", fmt.Errorf("port-forward: unsupported object type %T", target)
+	}
+}
+
+// PortForward forwards a local port to remotePort on target, a *v1.Pod or a
+// *v1.Service, using the API server portforward subresource. It returns the
+// local address, in the host:port form, clients can connect to. Contrary to
+// PodProxyGet, any TCP protocol can be used through the forwarded port.
+//
+// When forwarding to a Service, remotePort is the service port and the
+// connection is made to one of the pods backing the service.
+//
+// The port forward is stopped when the test is closed.
+func (test *Test) PortForward(target runtime.Object, remotePort int) string {
+	addr, err := test.portForward(target, remotePort)
+	test.err(err)
+	return addr
+}
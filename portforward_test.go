@@ -0,0 +1,43 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestContainerPort(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Ports: []v1.ContainerPort{
+					{Name: "http", ContainerPort: 8080},
+					{Name: "metrics", ContainerPort: 9090},
+				},
+			}},
+		},
+	}
+
+	tests := []struct {
+		port     intstr.IntOrString
+		expected int
+		valid    bool
+	}{
+		{intstr.FromInt(80), 80, true},
+		{intstr.FromString("http"), 8080, true},
+		{intstr.FromString("metrics"), 9090, true},
+		{intstr.FromString("grpc"), 0, false},
+	}
+
+	for _, test := range tests {
+		port, err := containerPort(pod, test.port)
+		if !test.valid {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, port)
+	}
+}
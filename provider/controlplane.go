@@ -0,0 +1,361 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// ControlPlane is a local control plane made of an etcd and a kube-apiserver
+// processes, started from the binaries distributed for envtest. It runs
+// offline and starts in seconds, but there's no controller manager, scheduler
+// or node: objects are stored and validated but never reconciled. It's
+// suitable to test controllers running in the test process.
+//
+// Harness helpers waiting for objects to be reconciled, eg.
+// WaitForDeploymentReady, fail with a harness.NoControllersError when using a
+// ControlPlane.
+type ControlPlane struct {
+	// BinaryDirectory is the directory containing the etcd and kube-apiserver
+	// binaries. Defaults to $KUBEBUILDER_ASSETS or, if not set,
+	// /usr/local/kubebuilder/bin.
+	BinaryDirectory string
+	// APIServerFlags are additional kube-apiserver flags, eg. to enable
+	// feature gates.
+	APIServerFlags []string
+	// StartTimeout is how long to wait for the API server to be ready.
+	// Defaults to 1 minute.
+	StartTimeout time.Duration
+	// KubeconfigPath is where to write the kubeconfig. Defaults to a file in
+	// the control plane data directory.
+	KubeconfigPath string
+
+	dir       string
+	port      int
+	token     string
+	etcd      *process
+	apiServer *process
+}
+
+// process is a running control plane component.
+type process struct {
+	name   string
+	cmd    *exec.Cmd
+	log    string
+	exited chan struct{}
+}
+
+// errAddressInUse is returned when a component can't bind its port: the port
+// returned by freePort has been taken by another process before the component
+// started.
+var errAddressInUse = errors.New("address already in use")
+
+// exitError returns the error of a component that has exited.
+func (p *process) exitError() error {
+	log, _ := ioutil.ReadFile(p.log)
+	if bytes.Contains(log, []byte("address already in use")) {
+		return fmt.Errorf("%s: %w, see %s", p.name, errAddressInUse, p.log)
+	}
+	return fmt.Errorf("%s exited, see %s", p.name, p.log)
+}
+
+func (p *process) stop() {
+	p.cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-p.exited:
+	case <-time.After(10 * time.Second):
+		p.cmd.Process.Kill()
+		<-p.exited
+	}
+}
+
+// binaryDirectory returns where the control plane binaries are.
+func (c *ControlPlane) binaryDirectory() string {
+	if c.BinaryDirectory != "" {
+		return c.BinaryDirectory
+	}
+	if dir := os.Getenv("KUBEBUILDER_ASSETS"); dir != "" {
+		return dir
+	}
+	return "/usr/local/kubebuilder/bin"
+}
+
+func (c *ControlPlane) binary(name string) (string, error) {
+	path := filepath.Join(c.binaryDirectory(), name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("%s not found in %s: install the envtest binaries and set KUBEBUILDER_ASSETS: %w", name, c.binaryDirectory(), err)
+	}
+	return path, nil
+}
+
+// freePort returns a TCP port available on the loopback interface.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// start starts a control plane component, logging its output in the data
+// directory.
+func (c *ControlPlane) start(name string, args ...string) (*process, error) {
+	path, err := c.binary(name)
+	if err != nil {
+		return nil, err
+	}
+
+	log := filepath.Join(c.dir, name+".log")
+	f, err := os.Create(log)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := execCommand(path, args...)
+	cmd.Stdout = f
+	cmd.Stderr = f
+	if err := cmd.Start(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("starting %s: %w", name, err)
+	}
+
+	p := &process{name: name, cmd: cmd, log: log, exited: make(chan struct{})}
+	go func() {
+		cmd.Wait()
+		f.Close()
+		close(p.exited)
+	}()
+	return p, nil
+}
+
+// writeServiceAccountKey generates the key used to sign service account
+// tokens.
+func writeServiceAccountKey(path string) error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// apiServerArgs returns the kube-apiserver flags. The admin token is a member
+// of system:masters. The ServiceAccount admission plugin is disabled as there's
+// no controller creating the default service accounts.
+func (c *ControlPlane) apiServerArgs(etcdPort int) []string {
+	args := []string{
+		"--etcd-servers=http://127.0.0.1:" + strconv.Itoa(etcdPort),
+		"--cert-dir=" + filepath.Join(c.dir, "certs"),
+		"--bind-address=127.0.0.1",
+		"--advertise-address=127.0.0.1",
+		"--secure-port=" + strconv.Itoa(c.port),
+		"--token-auth-file=" + filepath.Join(c.dir, "tokens.csv"),
+		"--authorization-mode=RBAC",
+		"--service-cluster-ip-range=10.0.0.0/24",
+		"--service-account-issuer=https://kubernetes.default.svc",
+		"--service-account-key-file=" + filepath.Join(c.dir, "sa.key"),
+		"--service-account-signing-key-file=" + filepath.Join(c.dir, "sa.key"),
+		"--disable-admission-plugins=ServiceAccount",
+		"--allow-privileged=true",
+	}
+	return append(args, c.APIServerFlags...)
+}
+
+// waitForAPIServer waits until the API server reports it's ready.
+func (c *ControlPlane) waitForAPIServer() error {
+	timeout := c.StartTimeout
+	if timeout == 0 {
+		timeout = time.Minute
+	}
+
+	client := &http.Client{
+		Timeout: time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	url := fmt.Sprintf("https://127.0.0.1:%d/readyz", c.port)
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-c.etcd.exited:
+			return c.etcd.exitError()
+		case <-c.apiServer.exited:
+			return c.apiServer.exitError()
+		default:
+		}
+
+		req, _ := http.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	return fmt.Errorf("kube-apiserver not ready after %s, see %s", timeout, c.apiServer.log)
+}
+
+// startAttempts is how many times Create tries to start the control plane when
+// a port is taken.
+const startAttempts = 3
+
+// Create starts etcd and kube-apiserver and waits for the API server to be
+// ready.
+func (c *ControlPlane) Create() error {
+	for attempt := 1; ; attempt++ {
+		var err error
+		c.dir, err = ioutil.TempDir("", "kube-test-harness-control-plane")
+		if err != nil {
+			return err
+		}
+		err = c.create()
+		if err == nil {
+			return nil
+		}
+		c.Delete()
+		if !errors.Is(err, errAddressInUse) || attempt == startAttempts {
+			return err
+		}
+	}
+}
+
+func (c *ControlPlane) create() error {
+	var ports [3]int
+	for i := range ports {
+		port, err := freePort()
+		if err != nil {
+			return err
+		}
+		ports[i] = port
+	}
+	etcdPort, peerPort := ports[0], ports[1]
+	c.port = ports[2]
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	c.token = token
+	tokens := fmt.Sprintf("%s,admin,admin,\"system:masters\"\n", token)
+	if err := ioutil.WriteFile(filepath.Join(c.dir, "tokens.csv"), []byte(tokens), 0600); err != nil {
+		return err
+	}
+	if err := writeServiceAccountKey(filepath.Join(c.dir, "sa.key")); err != nil {
+		return err
+	}
+
+	etcdURL := "http://127.0.0.1:" + strconv.Itoa(etcdPort)
+	c.etcd, err = c.start("etcd",
+		"--data-dir="+filepath.Join(c.dir, "etcd"),
+		"--listen-client-urls="+etcdURL,
+		"--advertise-client-urls="+etcdURL,
+		"--listen-peer-urls=http://127.0.0.1:"+strconv.Itoa(peerPort),
+	)
+	if err != nil {
+		return err
+	}
+
+	c.apiServer, err = c.start("kube-apiserver", c.apiServerArgs(etcdPort)...)
+	if err != nil {
+		return err
+	}
+
+	return c.waitForAPIServer()
+}
+
+func (c *ControlPlane) kubeconfigPath() string {
+	if c.KubeconfigPath != "" {
+		return c.KubeconfigPath
+	}
+	return filepath.Join(c.dir, "kubeconfig")
+}
+
+// controlPlaneKubeconfig returns a kubeconfig giving admin access to the API
+// server. The API server uses a self-signed certificate, generated at startup.
+func controlPlaneKubeconfig(port int, token string) []byte {
+	return []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: control-plane
+  cluster:
+    server: https://127.0.0.1:%d
+    insecure-skip-tls-verify: true
+users:
+- name: admin
+  user:
+    token: %s
+contexts:
+- name: control-plane
+  context:
+    cluster: control-plane
+    user: admin
+current-context: control-plane
+`, port, token))
+}
+
+// Kubeconfig writes the kubeconfig of the control plane and returns its path.
+func (c *ControlPlane) Kubeconfig() (string, error) {
+	if c.apiServer == nil {
+		return "", errors.New("control plane isn't running")
+	}
+	path := c.kubeconfigPath()
+	return path, writeKubeconfig(path, controlPlaneKubeconfig(c.port, c.token))
+}
+
+// Delete stops the control plane and removes its data.
+func (c *ControlPlane) Delete() error {
+	if c.apiServer != nil {
+		c.apiServer.stop()
+		c.apiServer = nil
+	}
+	if c.etcd != nil {
+		c.etcd.stop()
+		c.etcd = nil
+	}
+	if c.dir == "" {
+		return nil
+	}
+	err := os.RemoveAll(c.dir)
+	c.dir = ""
+	return err
+}
+
+// LoadImage isn't supported: the control plane has no node to run containers.
+func (c *ControlPlane) LoadImage(image string) error {
+	return errors.New("loading images isn't supported: the control plane has no node")
+}
+
+// ControlPlaneOnly tells the harness the cluster has no controllers.
+func (c *ControlPlane) ControlPlaneOnly() bool {
+	return true
+}
@@ -0,0 +1,32 @@
+package provider
+
+import "errors"
+
+// Existing is a cluster created outside of the harness. It's neither created
+// nor deleted.
+type Existing struct {
+	// Path is the path to the cluster kubeconfig. An empty path means the
+	// harness default kubeconfig.
+	Path string
+}
+
+// Create does nothing.
+func (e *Existing) Create() error {
+	return nil
+}
+
+// Kubeconfig returns Path.
+func (e *Existing) Kubeconfig() (string, error) {
+	return e.Path, nil
+}
+
+// Delete does nothing.
+func (e *Existing) Delete() error {
+	return nil
+}
+
+// LoadImage isn't supported: there's no generic way to load an image in an
+// existing cluster.
+func (e *Existing) LoadImage(image string) error {
+	return errors.New("loading images isn't supported for existing clusters")
+}
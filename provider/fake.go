@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"errors"
+	"sync"
+)
+
+// Fake is an in-memory cluster provider for unit tests. It records the calls
+// made by the harness.
+type Fake struct {
+	// Path is the kubeconfig returned by Kubeconfig once the cluster exists.
+	Path string
+	// Exists is whether the cluster exists.
+	Exists bool
+	// CreateError, when set, is returned by Create.
+	CreateError error
+
+	// Creates and Deletes count the calls to Create and Delete.
+	Creates, Deletes int
+	// Images are the images loaded with LoadImage.
+	Images []string
+
+	mu sync.Mutex
+}
+
+// Create creates the fake cluster.
+func (f *Fake) Create() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Creates++
+	if f.CreateError != nil {
+		return f.CreateError
+	}
+	f.Exists = true
+	return nil
+}
+
+// Kubeconfig returns Path if the cluster exists.
+func (f *Fake) Kubeconfig() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.Exists {
+		return "", errors.New("cluster doesn't exist")
+	}
+	return f.Path, nil
+}
+
+// Delete deletes the fake cluster.
+func (f *Fake) Delete() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Deletes++
+	f.Exists = false
+	return nil
+}
+
+// LoadImage records image.
+func (f *Fake) LoadImage(image string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.Exists {
+		return errors.New("cluster doesn't exist")
+	}
+	f.Images = append(f.Images, image)
+	return nil
+}
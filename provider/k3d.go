@@ -0,0 +1,46 @@
+package provider
+
+// K3d creates k3s clusters with k3d, https://k3d.io/.
+type K3d struct {
+	// Name is the name of the cluster. Defaults to "k3s-default".
+	Name string
+	// Args are additional arguments for k3d cluster create, eg. to choose the
+	// number of agents.
+	Args []string
+	// KubeconfigPath is where to write the cluster kubeconfig. Defaults to a
+	// file in the temporary directory. The default kubeconfig isn't modified.
+	KubeconfigPath string
+}
+
+func (k *K3d) name() string {
+	if k.Name == "" {
+		return "k3s-default"
+	}
+	return k.Name
+}
+
+// Create creates the cluster.
+func (k *K3d) Create() error {
+	args := append([]string{"cluster", "create", k.name(), "--wait", "--kubeconfig-update-default=false"}, k.Args...)
+	return run(nil, "k3d", args...)
+}
+
+// Kubeconfig writes the cluster kubeconfig and returns its path.
+func (k *K3d) Kubeconfig() (string, error) {
+	data, err := output("k3d", "kubeconfig", "get", k.name())
+	if err != nil {
+		return "", err
+	}
+	path := kubeconfigPath(k.KubeconfigPath, "k3d", k.name())
+	return path, writeKubeconfig(path, data)
+}
+
+// Delete deletes the cluster.
+func (k *K3d) Delete() error {
+	return run(nil, "k3d", "cluster", "delete", k.name())
+}
+
+// LoadImage imports a docker image in the cluster nodes.
+func (k *K3d) LoadImage(image string) error {
+	return run(nil, "k3d", "image", "import", image, "-c", k.name())
+}
@@ -0,0 +1,58 @@
+package provider
+
+// Kind creates clusters with kind, https://kind.sigs.k8s.io/.
+type Kind struct {
+	// Name is the name of the cluster. Defaults to "kind".
+	Name string
+	// NodeImage is the node image to use, eg. to choose the Kubernetes
+	// version. Defaults to the kind default node image.
+	NodeImage string
+	// Config is the path to a kind configuration file.
+	Config string
+	// KubeconfigPath is where to write the cluster kubeconfig. Defaults to a
+	// file in the temporary directory. The default kubeconfig isn't modified.
+	KubeconfigPath string
+}
+
+func (k *Kind) name() string {
+	if k.Name == "" {
+		return "kind"
+	}
+	return k.Name
+}
+
+func (k *Kind) kubeconfigPath() string {
+	return kubeconfigPath(k.KubeconfigPath, "kind", k.name())
+}
+
+// Create creates the cluster.
+func (k *Kind) Create() error {
+	args := []string{"create", "cluster", "--name", k.name(), "--kubeconfig", k.kubeconfigPath(), "--wait", "5m"}
+	if k.NodeImage != "" {
+		args = append(args, "--image", k.NodeImage)
+	}
+	if k.Config != "" {
+		args = append(args, "--config", k.Config)
+	}
+	return run(nil, "kind", args...)
+}
+
+// Kubeconfig writes the cluster kubeconfig and returns its path.
+func (k *Kind) Kubeconfig() (string, error) {
+	data, err := output("kind", "get", "kubeconfig", "--name", k.name())
+	if err != nil {
+		return "", err
+	}
+	path := k.kubeconfigPath()
+	return path, writeKubeconfig(path, data)
+}
+
+// Delete deletes the cluster.
+func (k *Kind) Delete() error {
+	return run(nil, "kind", "delete", "cluster", "--name", k.name(), "--kubeconfig", k.kubeconfigPath())
+}
+
+// LoadImage loads a docker image in the cluster nodes.
+func (k *Kind) LoadImage(image string) error {
+	return run(nil, "kind", "load", "docker-image", image, "--name", k.name())
+}
@@ -0,0 +1,50 @@
+package provider
+
+// Minikube creates clusters with minikube, https://minikube.sigs.k8s.io/.
+type Minikube struct {
+	// Profile is the name of the minikube profile. Defaults to "minikube".
+	Profile string
+	// Args are additional arguments for minikube start, eg. to choose a
+	// driver.
+	Args []string
+	// KubeconfigPath is where to write the cluster kubeconfig. Defaults to a
+	// file in the temporary directory. The default kubeconfig isn't modified.
+	KubeconfigPath string
+}
+
+func (m *Minikube) profile() string {
+	if m.Profile == "" {
+		return "minikube"
+	}
+	return m.Profile
+}
+
+// env makes minikube write the cluster configuration to the harness
+// kubeconfig.
+func (m *Minikube) env() []string {
+	return []string{"KUBECONFIG=" + kubeconfigPath(m.KubeconfigPath, "minikube", m.profile())}
+}
+
+// Create starts the cluster.
+func (m *Minikube) Create() error {
+	args := append([]string{"start", "-p", m.profile()}, m.Args...)
+	return run(m.env(), "minikube", args...)
+}
+
+// Kubeconfig writes the cluster kubeconfig and returns its path.
+func (m *Minikube) Kubeconfig() (string, error) {
+	if err := run(m.env(), "minikube", "update-context", "-p", m.profile()); err != nil {
+		return "", err
+	}
+	return kubeconfigPath(m.KubeconfigPath, "minikube", m.profile()), nil
+}
+
+// Delete deletes the cluster.
+func (m *Minikube) Delete() error {
+	return run(m.env(), "minikube", "delete", "-p", m.profile())
+}
+
+// LoadImage loads an image in the cluster.
+func (m *Minikube) LoadImage(image string) error {
+	return run(m.env(), "minikube", "image", "load", image, "-p", m.profile())
+}
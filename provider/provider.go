@@ -0,0 +1,68 @@
+// Package provider has implementations of harness.ClusterProvider, creating
+// the clusters tests run against with kind, minikube or k3d.
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Output is where the output of the cluster tools is written.
+var Output io.Writer = os.Stderr
+
+// execCommand is exec.Command, replaced in tests.
+var execCommand = exec.Command
+
+// command returns a command running a cluster tool with additional environment
+// variables.
+func command(env []string, name string, args ...string) *exec.Cmd {
+	cmd := execCommand(name, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
+
+// run runs a cluster tool, its output going to Output.
+func run(env []string, name string, args ...string) error {
+	cmd := command(env, name, args...)
+	cmd.Stdout = Output
+	cmd.Stderr = Output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// output runs a cluster tool and returns its standard output.
+func output(name string, args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd := command(nil, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = Output
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// kubeconfigPath returns path or, if empty, a default location for the
+// kubeconfig of cluster name created by tool.
+func kubeconfigPath(path, tool, name string) string {
+	if path != "" {
+		return path
+	}
+	return filepath.Join(os.TempDir(), "kube-test-harness", tool+"-"+name+".kubeconfig")
+}
+
+func writeKubeconfig(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
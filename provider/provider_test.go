@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	harness "github.com/dlespiau/kube-test-harness"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var (
+	_ harness.ClusterProvider = &Kind{}
+	_ harness.ClusterProvider = &Minikube{}
+	_ harness.ClusterProvider = &K3d{}
+	_ harness.ClusterProvider = &Existing{}
+	_ harness.ClusterProvider = &Fake{}
+	_ harness.ClusterProvider = &ControlPlane{}
+)
+
+// recordCommands replaces execCommand with a command printing stdout and
+// records the command lines.
+func recordCommands(t *testing.T, stdout string) *[][]string {
+	var commands [][]string
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		commands = append(commands, append([]string{name}, args...))
+		return exec.Command("echo", "-n", stdout)
+	}
+	t.Cleanup(func() { execCommand = exec.Command })
+	return &commands
+}
+
+func TestKind(t *testing.T) {
+	dir, err := ioutil.TempDir("", "provider")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	commands := recordCommands(t, "kubeconfig")
+	path := filepath.Join(dir, "kubeconfig")
+	kind := &Kind{Name: "test", NodeImage: "kindest/node:v1.20.0", KubeconfigPath: path}
+
+	assert.NoError(t, kind.Create())
+	kubeconfig, err := kind.Kubeconfig()
+	assert.NoError(t, err)
+	assert.Equal(t, path, kubeconfig)
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "kubeconfig", string(data))
+	assert.NoError(t, kind.LoadImage("app:dev"))
+	assert.NoError(t, kind.Delete())
+
+	assert.Equal(t, [][]string{
+		{"kind", "create", "cluster", "--name", "test", "--kubeconfig", path, "--wait", "5m", "--image", "kindest/node:v1.20.0"},
+		{"kind", "get", "kubeconfig", "--name", "test"},
+		{"kind", "load", "docker-image", "app:dev", "--name", "test"},
+		{"kind", "delete", "cluster", "--name", "test", "--kubeconfig", path},
+	}, *commands)
+}
+
+func TestMinikube(t *testing.T) {
+	commands := recordCommands(t, "")
+	minikube := &Minikube{Profile: "test", Args: []string{"--driver=docker"}, KubeconfigPath: "/tmp/kubeconfig"}
+
+	assert.NoError(t, minikube.Create())
+	kubeconfig, err := minikube.Kubeconfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/kubeconfig", kubeconfig)
+
+	assert.Equal(t, [][]string{
+		{"minikube", "start", "-p", "test", "--driver=docker"},
+		{"minikube", "update-context", "-p", "test"},
+	}, *commands)
+}
+
+func TestDefaultKubeconfigPath(t *testing.T) {
+	assert.Equal(t, "/a/b", kubeconfigPath("/a/b", "kind", "kind"))
+	assert.Equal(t, filepath.Join(os.TempDir(), "kube-test-harness", "k3d-dev.kubeconfig"), kubeconfigPath("", "k3d", "dev"))
+}
+
+func TestControlPlaneKubeconfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "provider")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "kubeconfig")
+	assert.NoError(t, ioutil.WriteFile(path, controlPlaneKubeconfig(6443, "secret"), 0600))
+	config, err := clientcmd.BuildConfigFromFlags("", path)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://127.0.0.1:6443", config.Host)
+	assert.Equal(t, "secret", config.BearerToken)
+	assert.True(t, config.Insecure)
+}
+
+func TestControlPlaneMissingBinaries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "provider")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cp := &ControlPlane{BinaryDirectory: dir}
+	err = cp.Create()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "etcd not found in "+dir)
+	assert.Empty(t, cp.dir)
+
+	_, err = cp.Kubeconfig()
+	assert.Error(t, err)
+}
+
+// writeScript writes an executable shell script in dir.
+func writeScript(t *testing.T, dir, name, script string) {
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755))
+}
+
+func TestControlPlaneStartFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		attempts int
+		inUse    bool
+	}{
+		{"address in use", "listen tcp 127.0.0.1:6443: bind: address already in use", startAttempts, true},
+		{"other failure", "invalid flag", 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "provider")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			writeScript(t, dir, "etcd", "exec sleep 60\n")
+			writeScript(t, dir, "kube-apiserver", `echo >> "$(dirname "$0")/attempts"
+echo "`+tt.output+`"
+exit 1
+`)
+
+			cp := &ControlPlane{BinaryDirectory: dir}
+			err = cp.Create()
+			assert.Error(t, err)
+			assert.Equal(t, tt.inUse, errors.Is(err, errAddressInUse))
+			assert.Empty(t, cp.dir)
+
+			attempts, err := ioutil.ReadFile(filepath.Join(dir, "attempts"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.attempts, strings.Count(string(attempts), "\n"))
+		})
+	}
+}
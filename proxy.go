@@ -0,0 +1,193 @@
+package harness
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+)
+
+// ProxyRequest is an HTTP request sent to a pod or a service through the API
+// server proxy.
+type ProxyRequest struct {
+	// Scheme is either "http" or "https". If not given, defaults to "http".
+	Scheme string
+	// Port is a port name or a port number. If not given, the first port found
+	// in the pod or service spec will be used.
+	Port string
+	// Method is the HTTP method. If not given, defaults to GET.
+	Method string
+	// Path is the request path, including an optional query string.
+	Path string
+	// Header holds the request headers.
+	Header http.Header
+	// Body is the request body.
+	Body []byte
+}
+
+// ProxyResponse is the HTTP response to a ProxyRequest or an IngressRequest.
+type ProxyResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// proxyName returns the name of the proxied object as understood by the API
+// server proxy: [scheme:]name[:port].
+func proxyName(name string, req *ProxyRequest) string {
+	if req.Scheme != "" && req.Scheme != "http" {
+		name = req.Scheme + ":" + name
+	}
+	if req.Port != "" {
+		name += ":" + req.Port
+	}
+	return name
+}
+
+func (test *Test) proxyDo(target runtime.Object, req *ProxyRequest) (*ProxyResponse, error) {
+	if err := test.needsControllers("ProxyDo"); err != nil {
+		return nil, err
+	}
+
+	var resource, namespace, name string
+	switch o := target.(type) {
+	case *v1.Pod:
+		resource, namespace, name = "pods", o.Namespace, o.Name
+	case *v1.Service:
+		resource, namespace, name = "services", o.Namespace, o.Name
+	default:
+		return nil, fmt.Errorf("proxy: unsupported object type %T", target)
+	}
+
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	path, err := url.Parse(req.Path)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: invalid path: %w", err)
+	}
+
+	u := test.kubeClient.CoreV1().RESTClient().
+		Verb(method).
+		Namespace(namespace).
+		Resource(resource).
+		Name(proxyName(name, req)).
+		SubResource("proxy").
+		Suffix(path.Path).
+		URL()
+	u.RawQuery = path.RawQuery
+
+	test.Debugf("proxy: %s %s %s%s", method, resource, name, req.Path)
+
+	httpReq, err := http.NewRequest(method, u.String(), bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+	for k, values := range req.Header {
+		for _, v := range values {
+			httpReq.Header.Add(k, v)
+		}
+	}
+
+	transport, err := rest.TransportFor(test.restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(httpReq.WithContext(context.TODO()))
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %s %s %s%s failed: %w", method, resource, name, req.Path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: failed to read response body: %w", err)
+	}
+
+	return &ProxyResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}, nil
+}
+
+// ProxyDo sends an HTTP request to target, a *v1.Pod or a *v1.Service, through
+// the API server proxy. Responses with a non-2xx status code are not
+// considered errors and are returned to the caller.
+func (test *Test) ProxyDo(target runtime.Object, req *ProxyRequest) *ProxyResponse {
+	resp, err := test.proxyDo(target, req)
+	test.err(err)
+	return resp
+}
+
+func (test *Test) waitForProxyStatus(target runtime.Object, req *ProxyRequest, expectedStatus int, timeout time.Duration) (*ProxyResponse, error) {
+	test.Debugf("waiting for %s to return status %d", req.Path, expectedStatus)
+
+	if err := test.needsControllers("WaitForProxyStatus"); err != nil {
+		return nil, err
+	}
+
+	var resp *ProxyResponse
+	lastStatus := 0
+	err := wait.Poll(time.Second, timeout, func() (bool, error) {
+		var err error
+		resp, err = test.proxyDo(target, req)
+		if err != nil {
+			test.Debugf("%v", err)
+			return false, nil
+		}
+		if resp.StatusCode != lastStatus {
+			lastStatus = resp.StatusCode
+			test.Debugf("proxy: status %d", lastStatus)
+		}
+		return resp.StatusCode == expectedStatus, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("waiting for status %d failed (last status: %d): %w", expectedStatus, lastStatus, err)
+	}
+	return resp, nil
+}
+
+// WaitForProxyStatus repeatedly sends req to target, a *v1.Pod or a
+// *v1.Service, through the API server proxy until the response has
+// expectedStatus as status code. It returns that response.
+func (test *Test) WaitForProxyStatus(target runtime.Object, req *ProxyRequest, expectedStatus int, timeout time.Duration) *ProxyResponse {
+	resp, err := test.waitForProxyStatus(target, req, expectedStatus, timeout)
+	test.err(err)
+	return resp
+}
+
+func (test *Test) proxyGetJSON(target runtime.Object, port, path string, v interface{}) error {
+	resp, err := test.proxyDo(target, &ProxyRequest{
+		Port:   port,
+		Path:   path,
+		Header: http.Header{"Accept": []string{"application/json"}},
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("proxy: GET %s: unexpected status %d: %s", path, resp.StatusCode, string(resp.Body))
+	}
+	return json.Unmarshal(resp.Body, v)
+}
+
+// ServiceProxyGetJSON performs an HTTP GET to a service through the API server
+// proxy and unmarshals the response body into v. Port can be a port name or
+// the port number.
+//
+// If port is "", the first port found in the service spec will be used.
+func (test *Test) ServiceProxyGetJSON(service *v1.Service, port, path string, v interface{}) {
+	test.err(test.proxyGetJSON(service, port, path, v))
+}
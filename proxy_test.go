@@ -0,0 +1,25 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyName(t *testing.T) {
+	tests := []struct {
+		req      ProxyRequest
+		expected string
+	}{
+		{ProxyRequest{}, "nginx"},
+		{ProxyRequest{Port: "80"}, "nginx:80"},
+		{ProxyRequest{Port: "http"}, "nginx:http"},
+		{ProxyRequest{Scheme: "http", Port: "80"}, "nginx:80"},
+		{ProxyRequest{Scheme: "https", Port: "443"}, "https:nginx:443"},
+		{ProxyRequest{Scheme: "https"}, "https:nginx"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, proxyName("nginx", &test.req))
+	}
+}
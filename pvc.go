@@ -0,0 +1,304 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func (test *Test) createPVC(namespace string, pvc *v1.PersistentVolumeClaim) error {
+	test.Debugf("creating pvc %s", pvc.Name)
+
+	pvc.Namespace = namespace
+	if _, err := test.kubeClient.CoreV1().PersistentVolumeClaims(namespace).Create(context.TODO(), pvc, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create pvc %s: %w", pvc.Name, err)
+	}
+	return nil
+}
+
+// CreatePVC creates a persistent volume claim in the given namespace.
+func (test *Test) CreatePVC(namespace string, pvc *v1.PersistentVolumeClaim) {
+	err := test.createPVC(namespace, pvc)
+	test.err(err)
+}
+
+func (test *Test) loadPVC(manifestPath string) (*v1.PersistentVolumeClaim, error) {
+	manifest, err := test.harness.openManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	pvc := v1.PersistentVolumeClaim{}
+	if err := yaml.NewYAMLOrJSONDecoder(manifest, 100).Decode(&pvc); err != nil {
+		return nil, fmt.Errorf("failed to decode pvc %s: %w", manifestPath, err)
+	}
+
+	return &pvc, nil
+}
+
+// LoadPVC loads a persistent volume claim from a YAML manifest. The path to the
+// manifest is relative to Harness.ManifestDirectory.
+func (test *Test) LoadPVC(manifestPath string) *v1.PersistentVolumeClaim {
+	pvc, err := test.loadPVC(manifestPath)
+	test.err(err)
+	return pvc
+}
+
+func (test *Test) createPVCFromFile(namespace string, manifestPath string) (*v1.PersistentVolumeClaim, error) {
+	pvc, err := test.loadPVC(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	err = test.createPVC(namespace, pvc)
+	if err != nil {
+		return nil, err
+	}
+	return pvc, nil
+}
+
+// CreatePVCFromFile creates a persistent volume claim from a manifest file in the given namespace.
+func (test *Test) CreatePVCFromFile(namespace string, manifestPath string) *v1.PersistentVolumeClaim {
+	pvc, err := test.createPVCFromFile(namespace, manifestPath)
+	test.err(err)
+	return pvc
+}
+
+// GetPVC returns a PersistentVolumeClaim object if it exists or error.
+func (test *Test) GetPVC(ns, name string) (*v1.PersistentVolumeClaim, error) {
+	pvc, err := test.kubeClient.CoreV1().PersistentVolumeClaims(ns).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return pvc, nil
+}
+
+func (test *Test) deletePVC(pvc *v1.PersistentVolumeClaim) error {
+	test.Debugf("deleting pvc %s", pvc.Name)
+
+	if err := test.kubeClient.CoreV1().PersistentVolumeClaims(pvc.Namespace).Delete(context.TODO(), pvc.Name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("deleting pvc %s failed: %w", pvc.Name, err)
+	}
+	return nil
+}
+
+// DeletePVC deletes a persistent volume claim.
+func (test *Test) DeletePVC(pvc *v1.PersistentVolumeClaim) {
+	err := test.deletePVC(pvc)
+	test.err(err)
+}
+
+// waitForPVCDeleted waits until a deleted pvc has disappeared from the cluster.
+func (test *Test) waitForPVCDeleted(pvc *v1.PersistentVolumeClaim, timeout time.Duration) error {
+	test.Debugf("waiting for pvc %s to be deleted", pvc.Name)
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		_, err := test.GetPVC(pvc.Namespace, pvc.Name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+
+			return false, err
+		}
+
+		return false, nil
+	})
+}
+
+// WaitForPVCDeleted waits until a deleted persistent volume claim has
+// disappeared from the cluster. Claims used by pods are only removed once those
+// pods are gone.
+func (test *Test) WaitForPVCDeleted(pvc *v1.PersistentVolumeClaim, timeout time.Duration) {
+	test.err(test.waitForPVCDeleted(pvc, timeout))
+}
+
+func (test *Test) waitForPVCBound(pvc *v1.PersistentVolumeClaim, timeout time.Duration) error {
+	test.Debugf("waiting for pvc %s to be bound", pvc.Name)
+
+	if err := test.needsControllers("WaitForPVCBound"); err != nil {
+		return err
+	}
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		current, err := test.GetPVC(pvc.Namespace, pvc.Name)
+		if err != nil {
+			return false, err
+		}
+
+		switch current.Status.Phase {
+		case v1.ClaimBound:
+			return true, nil
+		case v1.ClaimLost:
+			return false, fmt.Errorf("pvc %s has lost its volume", pvc.Name)
+		}
+		return false, nil
+	})
+}
+
+// WaitForPVCBound waits until a persistent volume claim is bound to a volume.
+// Note that with storage classes using the WaitForFirstConsumer binding mode,
+// claims are only bound once a pod using them is scheduled.
+func (test *Test) WaitForPVCBound(pvc *v1.PersistentVolumeClaim, timeout time.Duration) {
+	test.err(test.waitForPVCBound(pvc, timeout))
+}
+
+func (test *Test) resizePVC(pvc *v1.PersistentVolumeClaim, size resource.Quantity) error {
+	test.Debugf("resizing pvc %s to %s", pvc.Name, size.String())
+
+	current, err := test.GetPVC(pvc.Namespace, pvc.Name)
+	if err != nil {
+		return err
+	}
+	if current.Spec.Resources.Requests == nil {
+		current.Spec.Resources.Requests = v1.ResourceList{}
+	}
+	current.Spec.Resources.Requests[v1.ResourceStorage] = size
+
+	if _, err := test.kubeClient.CoreV1().PersistentVolumeClaims(pvc.Namespace).Update(context.TODO(), current, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("resizing pvc %s failed: %w", pvc.Name, err)
+	}
+	return nil
+}
+
+// ResizePVC requests a new size for a persistent volume claim. The storage
+// class needs to allow volume expansion.
+func (test *Test) ResizePVC(pvc *v1.PersistentVolumeClaim, size resource.Quantity) {
+	test.err(test.resizePVC(pvc, size))
+}
+
+// pvcResized returns whether the capacity of pvc has reached size.
+func pvcResized(pvc *v1.PersistentVolumeClaim, size resource.Quantity) bool {
+	for _, cond := range pvc.Status.Conditions {
+		if (cond.Type == v1.PersistentVolumeClaimResizing || cond.Type == v1.PersistentVolumeClaimFileSystemResizePending) &&
+			cond.Status == v1.ConditionTrue {
+			return false
+		}
+	}
+
+	capacity, ok := pvc.Status.Capacity[v1.ResourceStorage]
+	return ok && capacity.Cmp(size) >= 0
+}
+
+func (test *Test) waitForPVCResized(pvc *v1.PersistentVolumeClaim, size resource.Quantity, timeout time.Duration) error {
+	test.Debugf("waiting for pvc %s to be resized to %s", pvc.Name, size.String())
+
+	if err := test.needsControllers("WaitForPVCResized"); err != nil {
+		return err
+	}
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		current, err := test.GetPVC(pvc.Namespace, pvc.Name)
+		if err != nil {
+			return false, err
+		}
+		return pvcResized(current, size), nil
+	})
+}
+
+// WaitForPVCResized waits until the capacity of a persistent volume claim has
+// reached size, including the file system resize. Some volume plugins only
+// resize the file system when a pod uses the claim.
+func (test *Test) WaitForPVCResized(pvc *v1.PersistentVolumeClaim, size resource.Quantity, timeout time.Duration) {
+	test.err(test.waitForPVCResized(pvc, size, timeout))
+}
+
+const pvcMountPath = "/data"
+
+// createPVCPod creates a pod mounting pvc on /data and waits for it to be ready.
+func (test *Test) createPVCPod(pvc *v1.PersistentVolumeClaim, timeout time.Duration) (*v1.Pod, error) {
+	var zero int64
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: test.getObjID("pvc"),
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name:    "pvc",
+				Image:   ProbeImage,
+				Command: []string{"sleep", "86400"},
+				VolumeMounts: []v1.VolumeMount{{
+					Name:      "data",
+					MountPath: pvcMountPath,
+				}},
+			}},
+			Volumes: []v1.Volume{{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+						ClaimName: pvc.Name,
+					},
+				},
+			}},
+			TerminationGracePeriodSeconds: &zero,
+		},
+	}
+	if err := test.createPod(pvc.Namespace, pod); err != nil {
+		return nil, err
+	}
+	return test.waitForPodReady(pod, timeout)
+}
+
+func (test *Test) execShell(pod *v1.Pod, script string) (string, error) {
+	var stdout, stderr strings.Builder
+	if err := test.podExec(pod, "", []string{"sh", "-c", script}, nil, &stdout, &stderr); err != nil {
+		return "", fmt.Errorf("'%s' failed in pod %s: %w: %s", script, pod.Name, err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+func (test *Test) assertPVCDataPersists(pvc *v1.PersistentVolumeClaim, timeout time.Duration) error {
+	test.Debugf("checking data persists on pvc %s", pvc.Name)
+
+	marker := test.getObjID("marker")
+	path := pvcMountPath + "/." + marker
+
+	writer, err := test.createPVCPod(pvc, timeout)
+	if err != nil {
+		return err
+	}
+	if _, err := test.execShell(writer, fmt.Sprintf("echo %s > %s && sync", marker, path)); err != nil {
+		return err
+	}
+	if err := test.deletePod(writer); err != nil {
+		return err
+	}
+	if err := test.waitForPodDeleted(writer, timeout); err != nil {
+		return err
+	}
+
+	reader, err := test.createPVCPod(pvc, timeout)
+	if err != nil {
+		return err
+	}
+	data, err := test.execShell(reader, "cat "+path)
+	if err != nil {
+		return err
+	}
+	if _, err := test.execShell(reader, "rm -f "+path); err != nil {
+		return err
+	}
+	if err := test.deletePod(reader); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(data) != marker {
+		return fmt.Errorf("pvc %s: data hasn't persisted, read '%s', expected '%s'", pvc.Name, strings.TrimSpace(data), marker)
+	}
+	return nil
+}
+
+// AssertPVCDataPersists checks data written on a persistent volume claim
+// survives the pod that wrote it. A first pod writes a marker file on the
+// volume, is deleted and a second pod verifies the marker is still there.
+// Timeout applies to each of the steps waiting for a pod.
+func (test *Test) AssertPVCDataPersists(pvc *v1.PersistentVolumeClaim, timeout time.Duration) {
+	test.err(test.assertPVCDataPersists(pvc, timeout))
+}
@@ -0,0 +1,26 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestPVCResized(t *testing.T) {
+	size := resource.MustParse("2Gi")
+	pvc := &v1.PersistentVolumeClaim{}
+	assert.False(t, pvcResized(pvc, size))
+
+	pvc.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse("1Gi")}
+	assert.False(t, pvcResized(pvc, size))
+
+	pvc.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse("2048Mi")}
+	assert.True(t, pvcResized(pvc, size))
+
+	pvc.Status.Conditions = []v1.PersistentVolumeClaimCondition{
+		{Type: v1.PersistentVolumeClaimFileSystemResizePending, Status: v1.ConditionTrue},
+	}
+	assert.False(t, pvcResized(pvc, size))
+}
@@ -0,0 +1,244 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// RetryPolicy configures how API calls failing with a transient error, eg. a
+// timeout or a connection reset while the API server restarts, are retried.
+//
+// Safe (GET, HEAD) and idempotent (PUT) requests are retried on timeouts,
+// connection errors, 429 and 5xx responses. Other requests, eg. creating or
+// deleting an object, are only retried when the API server is known not to
+// have processed them: the connection couldn't be established or the request
+// was throttled with a 429.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of times an API call is retried. Zero
+	// disables retries.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry. The delay doubles
+	// with each retry. Defaults to 200ms.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries. Defaults to 5s.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy used when Options.RetryPolicy isn't
+// given.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     5,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+func (p *RetryPolicy) maxBackoff() time.Duration {
+	if p.MaxBackoff == 0 {
+		return 5 * time.Second
+	}
+	return p.MaxBackoff
+}
+
+// backoff returns the delay before retry number n, starting at 0.
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	initial, max := p.InitialBackoff, p.maxBackoff()
+	if initial == 0 {
+		initial = 200 * time.Millisecond
+	}
+
+	delay := initial
+	for i := 0; i < n && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// idempotent returns whether req can be sent again without changing the outcome
+// if the API server had already processed it.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut:
+		return true
+	}
+	return false
+}
+
+// proxied returns whether req goes through the API server proxy. The responses
+// are then the ones of the proxied application, eg. checked by
+// WaitForProxyStatus, and aren't retried.
+func proxied(req *http.Request) bool {
+	return strings.HasSuffix(req.URL.Path, "/proxy") || strings.Contains(req.URL.Path, "/proxy/")
+}
+
+// retriable returns why the outcome of req is a transient error worth a retry,
+// or "" if it isn't.
+func retriable(req *http.Request, resp *http.Response, err error) string {
+	if err != nil {
+		if req.Context().Err() != nil {
+			return ""
+		}
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return "connection error: " + err.Error()
+		}
+		if !idempotent(req) {
+			return ""
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return "timeout: " + err.Error()
+		}
+		if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return "connection error: " + err.Error()
+		}
+		return ""
+	}
+
+	switch {
+	case proxied(req):
+		return ""
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return resp.Status
+	case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented && idempotent(req):
+		return resp.Status
+	}
+	return ""
+}
+
+// retryAfter returns the delay asked by the API server in a Retry-After header.
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// retryTransport retries requests failing with a transient error.
+type retryTransport struct {
+	base    http.RoundTripper
+	policy  RetryPolicy
+	onRetry func(req *http.Request, reason string, retry int, delay time.Duration)
+}
+
+func (rt *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for retry := 0; ; retry++ {
+		resp, err := rt.base.RoundTrip(req)
+		if retry >= rt.policy.MaxRetries {
+			return resp, err
+		}
+		reason := retriable(req, resp, err)
+		if reason == "" {
+			return resp, err
+		}
+		// The body can't be sent again.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+
+		delay := rt.policy.backoff(retry)
+		if after := retryAfter(resp); after > delay && after <= rt.policy.maxBackoff() {
+			delay = after
+		}
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		rt.onRetry(req, reason, retry+1, delay)
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// newClient creates the client of a test. Unit tests replace it to use a fake
+// client.
+var newClient = func(config *rest.Config) (kubernetes.Interface, error) {
+	return kubernetes.NewForConfig(config)
+}
+
+// retryPolicy returns the retry policy of the harness.
+func (h *Harness) retryPolicy() RetryPolicy {
+	if h.options.RetryPolicy == nil {
+		return DefaultRetryPolicy
+	}
+	return *h.options.RetryPolicy
+}
+
+// retryConfig returns a copy of config whose API calls failing with a transient
+// error are retried according to policy.
+func retryConfig(config *rest.Config, policy RetryPolicy, onRetry func(req *http.Request, reason string, retry int, delay time.Duration)) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &retryTransport{
+			base:    rt,
+			policy:  policy,
+			onRetry: onRetry,
+		}
+	})
+	return config
+}
+
+// withRetries returns a copy of config, and its client, retrying the API calls
+// failing with a transient error. Retries are logged and counted in test.
+func (test *Test) withRetries(config *rest.Config) (*rest.Config, kubernetes.Interface, error) {
+	if config == nil {
+		return nil, nil, errors.New("no kubeconfig, call SetKubeconfig")
+	}
+
+	policy := test.harness.retryPolicy()
+	counted := test.homeTest()
+	config = retryConfig(config, policy, func(req *http.Request, reason string, retry int, delay time.Duration) {
+		atomic.AddInt64(&counted.retries, 1)
+		test.Debugf("retrying %s %s in %s (%d/%d): %s", req.Method, req.URL.Path, delay, retry, policy.MaxRetries, reason)
+	})
+
+	client, err := newClient(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return config, client, nil
+}
+
+// Retries returns the number of API calls of the test retried because of a
+// transient error. See Options.RetryPolicy.
+func (test *Test) Retries() int {
+	return int(atomic.LoadInt64(&test.homeTest().retries))
+}
+
+// reportRetries logs the number of retried API calls.
+func (test *Test) reportRetries() {
+	if n := test.Retries(); n > 0 {
+		test.Infof("%d API calls retried because of transient errors", n)
+	}
+}
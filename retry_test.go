@@ -0,0 +1,156 @@
+package harness
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlespiau/kube-test-harness/logger"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	assert.Equal(t, 100*time.Millisecond, p.backoff(0))
+	assert.Equal(t, 200*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 800*time.Millisecond, p.backoff(3))
+	assert.Equal(t, time.Second, p.backoff(4))
+	assert.Equal(t, time.Second, p.backoff(100))
+
+	p = &RetryPolicy{}
+	assert.Equal(t, 200*time.Millisecond, p.backoff(0))
+	assert.Equal(t, 5*time.Second, p.backoff(10))
+}
+
+// flakyServer fails the first failures requests with status.
+func flakyServer(failures, status int) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	return server, &requests
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		failures int
+		status   int
+		requests int
+		retries  int
+	}{
+		{"get 503", "GET", "/api/v1/pods", 2, http.StatusServiceUnavailable, 3, 2},
+		{"get 504 too many", "GET", "/api/v1/pods", 5, http.StatusGatewayTimeout, 4, 3},
+		{"get 404", "GET", "/api/v1/pods", 1, http.StatusNotFound, 1, 0},
+		{"put 500", "PUT", "/api/v1/pods/a", 1, http.StatusInternalServerError, 2, 1},
+		{"post 503", "POST", "/api/v1/pods", 1, http.StatusServiceUnavailable, 1, 0},
+		{"post 429", "POST", "/api/v1/pods", 1, http.StatusTooManyRequests, 2, 1},
+		{"delete 429", "DELETE", "/api/v1/pods/a", 1, http.StatusTooManyRequests, 2, 1},
+		{"proxy 503", "GET", "/api/v1/namespaces/ns/services/app/proxy/", 1, http.StatusServiceUnavailable, 1, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, requests := flakyServer(test.failures, test.status)
+			defer server.Close()
+
+			retries := 0
+			rt := &retryTransport{
+				base:   http.DefaultTransport,
+				policy: RetryPolicy{MaxRetries: 3, InitialBackoff: time.Millisecond},
+				onRetry: func(*http.Request, string, int, time.Duration) {
+					retries++
+				},
+			}
+			req, err := http.NewRequest(test.method, server.URL+test.path, strings.NewReader("{}"))
+			assert.NoError(t, err)
+			resp, err := rt.RoundTrip(req)
+			assert.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, test.requests, *requests)
+			assert.Equal(t, test.retries, retries)
+		})
+	}
+}
+
+func TestRetryConnectionRefused(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	retries := 0
+	rt := &retryTransport{
+		base:   http.DefaultTransport,
+		policy: RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond},
+		onRetry: func(*http.Request, string, int, time.Duration) {
+			retries++
+		},
+	}
+	req, err := http.NewRequest("POST", url+"/api/v1/pods", strings.NewReader("{}"))
+	assert.NoError(t, err)
+	_, err = rt.RoundTrip(req)
+	assert.Error(t, err)
+	assert.Equal(t, 2, retries)
+}
+
+func TestTestRetries(t *testing.T) {
+	server, requests := flakyServer(1, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	test := &Test{
+		harness: &Harness{options: Options{
+			RetryPolicy: &RetryPolicy{MaxRetries: 1, InitialBackoff: time.Millisecond},
+		}},
+		t:      t,
+		logger: (&logger.TestLogger{}).ForTest(t),
+	}
+	_, client, err := test.withRetries(&rest.Config{Host: server.URL})
+	assert.NoError(t, err)
+
+	_, err = client.CoreV1().Pods("ns").List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, *requests)
+	assert.Equal(t, 1, test.Retries())
+}
+
+func TestTestRetriesNoKubeconfig(t *testing.T) {
+	test := &Test{harness: &Harness{}, t: t}
+	_, _, err := test.withRetries(nil)
+	assert.EqualError(t, err, "no kubeconfig, call SetKubeconfig")
+}
+
+func TestHarnessClientRetries(t *testing.T) {
+	server, requests := flakyServer(1, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	kubeconfig := writeTestKubeconfig(t)
+	data, err := ioutil.ReadFile(kubeconfig)
+	assert.NoError(t, err)
+	data = []byte(strings.Replace(string(data), "https://127.0.0.1:6443", server.URL, 1))
+	assert.NoError(t, ioutil.WriteFile(kubeconfig, data, 0600))
+
+	h := New(Options{
+		RetryPolicy: &RetryPolicy{MaxRetries: 1, InitialBackoff: time.Millisecond},
+		Logger:      &logger.PrintfLogger{},
+	})
+	assert.NoError(t, h.SetKubeconfig(kubeconfig))
+
+	_, err = h.KubeClient().CoreV1().Pods("ns").List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, *requests)
+}
@@ -0,0 +1,287 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// revisionAnnotation is set by the deployment controller on ReplicaSets.
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+	// controllerRevisionHashLabel is set on ControllerRevisions and on the
+	// pods of DaemonSets and StatefulSets.
+	controllerRevisionHashLabel = "controller-revision-hash"
+)
+
+// Revision is an entry of the rollout history of a Deployment, DaemonSet or
+// StatefulSet.
+type Revision struct {
+	// Number is the revision number, as shown by kubectl rollout history.
+	Number int64
+	// Name is the name of the ReplicaSet, for Deployments, or of the
+	// ControllerRevision, for DaemonSets and StatefulSets.
+	Name string
+	// Hash is the pod-template-hash label of the pods of a Deployment
+	// revision or the controller-revision-hash label of the pods of a
+	// DaemonSet or StatefulSet revision.
+	Hash string
+}
+
+func sortRevisions(revisions []Revision) {
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Number < revisions[j].Number
+	})
+}
+
+// findRevision returns the revision with the given number. Number 0 designates
+// the revision before the latest one, like kubectl rollout undo does.
+func findRevision(revisions []Revision, number int64) (*Revision, error) {
+	if number == 0 {
+		if len(revisions) < 2 {
+			return nil, fmt.Errorf("no previous revision to roll back to")
+		}
+		return &revisions[len(revisions)-2], nil
+	}
+	for i := range revisions {
+		if revisions[i].Number == number {
+			return &revisions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("revision %d not found", number)
+}
+
+// deploymentRevisions returns the revisions of d from the ReplicaSets it owns.
+func deploymentRevisions(d *appsv1.Deployment, replicaSets []appsv1.ReplicaSet) []Revision {
+	revisions := []Revision{}
+	for _, rs := range replicaSets {
+		if !isOwnedBy(&rs, "Deployment", d.Name) {
+			continue
+		}
+		number, err := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		revisions = append(revisions, Revision{
+			Number: number,
+			Name:   rs.Name,
+			Hash:   rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey],
+		})
+	}
+	sortRevisions(revisions)
+	return revisions
+}
+
+// controllerRevisions returns the revisions of the object of the given kind
+// and name from the ControllerRevisions it owns.
+func controllerRevisions(kind, name string, crs []appsv1.ControllerRevision) []Revision {
+	revisions := []Revision{}
+	for _, cr := range crs {
+		if !isOwnedBy(&cr, kind, name) {
+			continue
+		}
+		revisions = append(revisions, Revision{
+			Number: cr.Revision,
+			Name:   cr.Name,
+			Hash:   cr.Labels[controllerRevisionHashLabel],
+		})
+	}
+	sortRevisions(revisions)
+	return revisions
+}
+
+func (test *Test) listReplicaSetsFromDeployment(d *appsv1.Deployment) ([]appsv1.ReplicaSet, error) {
+	selector, err := selectorToString(d.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	rsl, err := test.kubeClient.AppsV1().ReplicaSets(d.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing replicasets of deployment %s failed: %w", d.Name, err)
+	}
+	return rsl.Items, nil
+}
+
+func (test *Test) listDeploymentRevisions(d *appsv1.Deployment) ([]Revision, error) {
+	replicaSets, err := test.listReplicaSetsFromDeployment(d)
+	if err != nil {
+		return nil, err
+	}
+	return deploymentRevisions(d, replicaSets), nil
+}
+
+// ListDeploymentRevisions returns the rollout history of a deployment, oldest
+// revision first. Revisions are derived from the ReplicaSets owned by the
+// deployment, so only the revisions within the deployment revisionHistoryLimit
+// are returned.
+func (test *Test) ListDeploymentRevisions(d *appsv1.Deployment) []Revision {
+	revisions, err := test.listDeploymentRevisions(d)
+	test.err(err)
+	return revisions
+}
+
+func (test *Test) listControllerRevisions(namespace string, selector *metav1.LabelSelector, kind, name string) ([]Revision, error) {
+	s, err := selectorToString(selector)
+	if err != nil {
+		return nil, err
+	}
+	crl, err := test.kubeClient.AppsV1().ControllerRevisions(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: s,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing controller revisions of %s failed: %w", name, err)
+	}
+	return controllerRevisions(kind, name, crl.Items), nil
+}
+
+// ListDaemonSetRevisions returns the rollout history of a daemonset, oldest
+// revision first.
+func (test *Test) ListDaemonSetRevisions(d *appsv1.DaemonSet) []Revision {
+	revisions, err := test.listControllerRevisions(d.Namespace, d.Spec.Selector, "DaemonSet", d.Name)
+	test.err(err)
+	return revisions
+}
+
+// ListStatefulSetRevisions returns the rollout history of a statefulset, oldest
+// revision first.
+func (test *Test) ListStatefulSetRevisions(s *appsv1.StatefulSet) []Revision {
+	revisions, err := test.listControllerRevisions(s.Namespace, s.Spec.Selector, "StatefulSet", s.Name)
+	test.err(err)
+	return revisions
+}
+
+// rollbackTemplate returns the pod template of a deployment revision.
+func rollbackTemplate(rs *appsv1.ReplicaSet) v1.PodTemplateSpec {
+	template := *rs.Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	return template
+}
+
+func (test *Test) rollbackDeployment(d *appsv1.Deployment, number int64) error {
+	test.Debugf("rolling back deployment %s to revision %d", d.Name, number)
+
+	revisions, err := test.listDeploymentRevisions(d)
+	if err != nil {
+		return err
+	}
+	revision, err := findRevision(revisions, number)
+	if err != nil {
+		return fmt.Errorf("deployment %s: %w", d.Name, err)
+	}
+	rs, err := test.kubeClient.AppsV1().ReplicaSets(d.Namespace).Get(context.TODO(), revision.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	template := rollbackTemplate(rs)
+	return test.update(d, func(o runtime.Object) {
+		o.(*appsv1.Deployment).Spec.Template = template
+	})
+}
+
+// RollbackDeployment rolls a deployment back to the pod template of the given
+// revision. Revision 0 designates the revision before the latest one, like
+// kubectl rollout undo does. The rollback itself creates a new revision. d is
+// updated with the new deployment spec, use WaitForDeploymentRolledOut to wait
+// for the rollback to complete.
+func (test *Test) RollbackDeployment(d *appsv1.Deployment, revision int64) {
+	test.err(test.rollbackDeployment(d, revision))
+}
+
+func (test *Test) rollbackFromControllerRevision(obj runtime.Object, namespace string, selector *metav1.LabelSelector, kind, name string, number int64) error {
+	test.Debugf("rolling back %s %s to revision %d", kind, name, number)
+
+	revisions, err := test.listControllerRevisions(namespace, selector, kind, name)
+	if err != nil {
+		return err
+	}
+	revision, err := findRevision(revisions, number)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", kind, name, err)
+	}
+	cr, err := test.kubeClient.AppsV1().ControllerRevisions(namespace).Get(context.TODO(), revision.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	// ControllerRevisions hold a strategic merge patch restoring the pod
+	// template of the revision.
+	return test.patch(obj, types.StrategicMergePatchType, cr.Data.Raw)
+}
+
+// RollbackDaemonSet rolls a daemonset back to the pod template of the given
+// revision. Revision 0 designates the revision before the latest one. Use
+// WaitForDaemonSetRolledOut to wait for the rollback to complete.
+func (test *Test) RollbackDaemonSet(d *appsv1.DaemonSet, revision int64) {
+	test.err(test.rollbackFromControllerRevision(d, d.Namespace, d.Spec.Selector, "DaemonSet", d.Name, revision))
+}
+
+// RollbackStatefulSet rolls a statefulset back to the pod template of the
+// given revision. Revision 0 designates the revision before the latest one.
+// Use WaitForStatefulSetRolledOut to wait for the rollback to complete.
+func (test *Test) RollbackStatefulSet(s *appsv1.StatefulSet, revision int64) {
+	test.err(test.rollbackFromControllerRevision(s, s.Namespace, s.Spec.Selector, "StatefulSet", s.Name, revision))
+}
+
+// servingHashes returns the number of ready pods per value of the label key.
+func (test *Test) servingHashes(pods []v1.Pod, key string) map[string]int {
+	hashes := make(map[string]int)
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if ready, _ := test.PodReady(pod); !ready {
+			continue
+		}
+		hashes[pod.Labels[key]]++
+	}
+	return hashes
+}
+
+func (test *Test) assertDeploymentPodTemplateHash(d *appsv1.Deployment, hash string) error {
+	pl, err := test.listPodsFromDeployment(d)
+	if err != nil {
+		return err
+	}
+
+	hashes := test.servingHashes(pl.Items, appsv1.DefaultDeploymentUniqueLabelKey)
+	if len(hashes) == 1 && hashes[hash] > 0 {
+		return nil
+	}
+	return fmt.Errorf("deployment %s: expected ready pods to have pod-template-hash %s, found %v", d.Name, hash, hashes)
+}
+
+// AssertDeploymentPodTemplateHash checks all the ready pods of a deployment
+// have the given pod-template-hash, ie. the deployment is only serving from
+// that revision. Revision hashes are returned by ListDeploymentRevisions.
+func (test *Test) AssertDeploymentPodTemplateHash(d *appsv1.Deployment, hash string) {
+	test.err(test.assertDeploymentPodTemplateHash(d, hash))
+}
+
+func (test *Test) assertDeploymentRevision(d *appsv1.Deployment, number int64) error {
+	revisions, err := test.listDeploymentRevisions(d)
+	if err != nil {
+		return err
+	}
+	for _, revision := range revisions {
+		if revision.Number == number {
+			return test.assertDeploymentPodTemplateHash(d, revision.Hash)
+		}
+	}
+	return fmt.Errorf("deployment %s: revision %d not found", d.Name, number)
+}
+
+// AssertDeploymentRevision checks all the ready pods of a deployment belong to
+// the given revision.
+func (test *Test) AssertDeploymentRevision(d *appsv1.Deployment, revision int64) {
+	test.err(test.assertDeploymentRevision(d, revision))
+}
@@ -0,0 +1,93 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeploymentRevisions(t *testing.T) {
+	d := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web"}}
+	newReplicaSet := func(name, owner, revision, hash string) appsv1.ReplicaSet {
+		return appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Labels:          map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: hash},
+				Annotations:     map[string]string{revisionAnnotation: revision},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: owner}},
+			},
+		}
+	}
+
+	revisions := deploymentRevisions(d, []appsv1.ReplicaSet{
+		newReplicaSet("web-bbb", "web", "3", "bbb"),
+		newReplicaSet("web-aaa", "web", "1", "aaa"),
+		newReplicaSet("other-ccc", "other", "2", "ccc"),
+		newReplicaSet("web-ddd", "web", "", "ddd"),
+	})
+	assert.Equal(t, []Revision{
+		{Number: 1, Name: "web-aaa", Hash: "aaa"},
+		{Number: 3, Name: "web-bbb", Hash: "bbb"},
+	}, revisions)
+}
+
+func TestControllerRevisions(t *testing.T) {
+	newRevision := func(name, owner string, revision int64) appsv1.ControllerRevision {
+		return appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Labels:          map[string]string{controllerRevisionHashLabel: name},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: owner}},
+			},
+			Revision: revision,
+		}
+	}
+
+	revisions := controllerRevisions("StatefulSet", "db", []appsv1.ControllerRevision{
+		newRevision("db-2", "db", 2),
+		newRevision("cache-1", "cache", 1),
+		newRevision("db-1", "db", 1),
+	})
+	assert.Equal(t, []Revision{
+		{Number: 1, Name: "db-1", Hash: "db-1"},
+		{Number: 2, Name: "db-2", Hash: "db-2"},
+	}, revisions)
+	assert.Empty(t, controllerRevisions("DaemonSet", "db", nil))
+}
+
+func TestFindRevision(t *testing.T) {
+	revisions := []Revision{{Number: 1}, {Number: 2}, {Number: 4}}
+
+	r, err := findRevision(revisions, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), r.Number)
+
+	r, err = findRevision(revisions, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), r.Number)
+
+	_, err = findRevision(revisions, 3)
+	assert.Error(t, err)
+
+	_, err = findRevision(revisions[:1], 0)
+	assert.Error(t, err)
+}
+
+func TestRollbackTemplate(t *testing.T) {
+	rs := &appsv1.ReplicaSet{
+		Spec: appsv1.ReplicaSetSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": "web", appsv1.DefaultDeploymentUniqueLabelKey: "aaa"},
+				},
+			},
+		},
+	}
+
+	template := rollbackTemplate(rs)
+	assert.Equal(t, map[string]string{"app": "web"}, template.Labels)
+	assert.Contains(t, rs.Spec.Template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+}
@@ -0,0 +1,160 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func (test *Test) createRole(namespace string, role *rbacv1.Role) error {
+	test.Debugf("creating role %s", role.Name)
+
+	role.Namespace = namespace
+	if _, err := test.kubeClient.RbacV1().Roles(namespace).Create(context.TODO(), role, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create role %s: %w", role.Name, err)
+	}
+	return nil
+}
+
+// CreateRole creates a role in the given namespace.
+func (test *Test) CreateRole(namespace string, role *rbacv1.Role) {
+	err := test.createRole(namespace, role)
+	test.err(err)
+}
+
+func (test *Test) loadRole(manifestPath string) (*rbacv1.Role, error) {
+	manifest, err := test.harness.openManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	role := rbacv1.Role{}
+	if err := yaml.NewYAMLOrJSONDecoder(manifest, 100).Decode(&role); err != nil {
+		return nil, fmt.Errorf("failed to decode role %s: %w", manifestPath, err)
+	}
+	return &role, nil
+}
+
+// LoadRole loads a role from a YAML manifest. The path to the manifest is
+// relative to Harness.ManifestDirectory.
+func (test *Test) LoadRole(manifestPath string) *rbacv1.Role {
+	role, err := test.loadRole(manifestPath)
+	test.err(err)
+	return role
+}
+
+func (test *Test) createRoleFromFile(namespace string, manifestPath string) (*rbacv1.Role, error) {
+	role, err := test.loadRole(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	err = test.createRole(namespace, role)
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// CreateRoleFromFile creates a role from a manifest file in the given namespace.
+func (test *Test) CreateRoleFromFile(namespace string, manifestPath string) *rbacv1.Role {
+	role, err := test.createRoleFromFile(namespace, manifestPath)
+	test.err(err)
+	return role
+}
+
+func (test *Test) deleteRole(role *rbacv1.Role) error {
+	test.Debugf("deleting role %s", role.Name)
+
+	if err := test.kubeClient.RbacV1().Roles(role.Namespace).Delete(context.TODO(), role.Name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("deleting role %s failed: %w", role.Name, err)
+	}
+	return nil
+}
+
+// DeleteRole deletes a role.
+func (test *Test) DeleteRole(role *rbacv1.Role) {
+	err := test.deleteRole(role)
+	test.err(err)
+}
+
+// GetRole returns a Role object if it exists or error.
+func (test *Test) GetRole(ns, name string) (*rbacv1.Role, error) {
+	role, err := test.kubeClient.RbacV1().Roles(ns).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// rbacPollInterval is how often the RBAC waits check whether an object has
+// been created. Unit tests shorten it.
+var rbacPollInterval = time.Second
+
+func (test *Test) waitForRoleReady(ns, name string, timeout time.Duration) error {
+	test.Debugf("waiting for role %s to be ready", name)
+
+	return wait.Poll(rbacPollInterval, timeout, func() (bool, error) {
+		_, err := test.GetRole(ns, name)
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+// WaitForRoleReady waits until Role is created, otherwise times out.
+func (test *Test) WaitForRoleReady(role *rbacv1.Role, timeout time.Duration) {
+	err := test.waitForRoleReady(role.Namespace, role.Name, timeout)
+	test.err(err)
+}
+
+func (test *Test) grantServiceAccount(sa *v1.ServiceAccount, rules []rbacv1.PolicyRule) (*rbacv1.Role, *rbacv1.RoleBinding, error) {
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: test.getObjID("role"),
+		},
+		Rules: rules,
+	}
+	if err := test.createRole(sa.Namespace, role); err != nil {
+		return nil, nil, err
+	}
+
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: test.getObjID("rolebinding"),
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      sa.Name,
+			Namespace: sa.Namespace,
+		}},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role.Name,
+		},
+	}
+	if err := test.createRoleBinding(sa.Namespace, binding); err != nil {
+		return nil, nil, err
+	}
+
+	return role, binding, nil
+}
+
+// GrantServiceAccount gives a service account the permissions described by
+// rules in the service account namespace. It creates a Role with those rules
+// and binds it to the service account, returning both objects.
+func (test *Test) GrantServiceAccount(sa *v1.ServiceAccount, rules []rbacv1.PolicyRule) (*rbacv1.Role, *rbacv1.RoleBinding) {
+	role, binding, err := test.grantServiceAccount(sa, rules)
+	test.err(err)
+	return role, binding
+}
@@ -0,0 +1,139 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForRBACReady(t *testing.T) {
+	defer func(saved time.Duration) { rbacPollInterval = saved }(rbacPollInterval)
+	rbacPollInterval = 10 * time.Millisecond
+
+	meta := metav1.ObjectMeta{Namespace: "ns", Name: "reader"}
+	clusterMeta := metav1.ObjectMeta{Name: "reader"}
+
+	kinds := []struct {
+		kind   string
+		object runtime.Object
+		create func(client *fake.Clientset) error
+		wait   func(test *Test, timeout time.Duration) error
+	}{{
+		kind:   "Role",
+		object: &rbacv1.Role{ObjectMeta: meta},
+		create: func(client *fake.Clientset) error {
+			_, err := client.RbacV1().Roles("ns").Create(context.TODO(), &rbacv1.Role{ObjectMeta: meta}, metav1.CreateOptions{})
+			return err
+		},
+		wait: func(test *Test, timeout time.Duration) error {
+			return test.waitForRoleReady("ns", "reader", timeout)
+		},
+	}, {
+		kind:   "RoleBinding",
+		object: &rbacv1.RoleBinding{ObjectMeta: meta},
+		create: func(client *fake.Clientset) error {
+			_, err := client.RbacV1().RoleBindings("ns").Create(context.TODO(), &rbacv1.RoleBinding{ObjectMeta: meta}, metav1.CreateOptions{})
+			return err
+		},
+		wait: func(test *Test, timeout time.Duration) error {
+			return test.waitForRoleBindingReady("ns", "reader", timeout)
+		},
+	}, {
+		kind:   "ClusterRole",
+		object: &rbacv1.ClusterRole{ObjectMeta: clusterMeta},
+		create: func(client *fake.Clientset) error {
+			_, err := client.RbacV1().ClusterRoles().Create(context.TODO(), &rbacv1.ClusterRole{ObjectMeta: clusterMeta}, metav1.CreateOptions{})
+			return err
+		},
+		wait: func(test *Test, timeout time.Duration) error {
+			return test.waitForClusterRoleReady("reader", timeout)
+		},
+	}, {
+		kind:   "ClusterRoleBinding",
+		object: &rbacv1.ClusterRoleBinding{ObjectMeta: clusterMeta},
+		create: func(client *fake.Clientset) error {
+			_, err := client.RbacV1().ClusterRoleBindings().Create(context.TODO(), &rbacv1.ClusterRoleBinding{ObjectMeta: clusterMeta}, metav1.CreateOptions{})
+			return err
+		},
+		wait: func(test *Test, timeout time.Duration) error {
+			return test.waitForClusterRoleBindingReady("reader", timeout)
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		existing bool
+		delayed  bool
+		err      error
+	}{
+		{"exists", true, false, nil},
+		{"created later", false, true, nil},
+		{"never created", false, false, wait.ErrWaitTimeout},
+	}
+
+	for _, kind := range kinds {
+		for _, tt := range tests {
+			t.Run(kind.kind+"/"+tt.name, func(t *testing.T) {
+				var objects []runtime.Object
+				if tt.existing {
+					objects = append(objects, kind.object.DeepCopyObject())
+				}
+				test, client := newFakeTest(t, objects...)
+				if tt.delayed {
+					go func() {
+						time.Sleep(50 * time.Millisecond)
+						assert.NoError(t, kind.create(client))
+					}()
+				}
+
+				err := kind.wait(test, 200*time.Millisecond)
+				assert.True(t, errors.Is(err, tt.err), "unexpected error: %v", err)
+			})
+		}
+	}
+}
+
+func TestGrantServiceAccount(t *testing.T) {
+	sa := &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app"}}
+	rules := []rbacv1.PolicyRule{{
+		Verbs:     []string{"get", "list"},
+		APIGroups: []string{""},
+		Resources: []string{"configmaps"},
+	}}
+
+	tests := []struct {
+		name  string
+		rules []rbacv1.PolicyRule
+	}{
+		{"rules", rules},
+		{"no rules", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test, client := newFakeTest(t)
+
+			role, binding, err := test.grantServiceAccount(sa, tt.rules)
+			assert.NoError(t, err)
+			assert.Equal(t, "ns", role.Namespace)
+			assert.Equal(t, tt.rules, role.Rules)
+			assert.Equal(t, "ns", binding.Namespace)
+			assert.Equal(t, []rbacv1.Subject{{Kind: "ServiceAccount", Namespace: "ns", Name: "app"}}, binding.Subjects)
+			assert.Equal(t, rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: role.Name}, binding.RoleRef)
+
+			_, err = client.RbacV1().Roles("ns").Get(context.TODO(), role.Name, metav1.GetOptions{})
+			assert.NoError(t, err)
+			_, err = client.RbacV1().RoleBindings("ns").Get(context.TODO(), binding.Name, metav1.GetOptions{})
+			assert.NoError(t, err)
+		})
+	}
+}
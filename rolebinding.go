@@ -0,0 +1,113 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func (test *Test) createRoleBinding(namespace string, rb *rbacv1.RoleBinding) error {
+	test.Debugf("creating role binding %s", rb.Name)
+
+	rb.Namespace = namespace
+	if _, err := test.kubeClient.RbacV1().RoleBindings(namespace).Create(context.TODO(), rb, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create role binding %s: %w", rb.Name, err)
+	}
+	return nil
+}
+
+// CreateRoleBinding creates a role binding in the given namespace.
+func (test *Test) CreateRoleBinding(namespace string, rb *rbacv1.RoleBinding) {
+	err := test.createRoleBinding(namespace, rb)
+	test.err(err)
+}
+
+func (test *Test) loadRoleBinding(manifestPath string) (*rbacv1.RoleBinding, error) {
+	manifest, err := test.harness.openManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	rb := rbacv1.RoleBinding{}
+	if err := yaml.NewYAMLOrJSONDecoder(manifest, 100).Decode(&rb); err != nil {
+		return nil, fmt.Errorf("failed to decode role binding %s: %w", manifestPath, err)
+	}
+	return &rb, nil
+}
+
+// LoadRoleBinding loads a role binding from a YAML manifest. The path to the
+// manifest is relative to Harness.ManifestDirectory.
+func (test *Test) LoadRoleBinding(manifestPath string) *rbacv1.RoleBinding {
+	rb, err := test.loadRoleBinding(manifestPath)
+	test.err(err)
+	return rb
+}
+
+func (test *Test) createRoleBindingFromFile(namespace string, manifestPath string) (*rbacv1.RoleBinding, error) {
+	rb, err := test.loadRoleBinding(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	err = test.createRoleBinding(namespace, rb)
+	if err != nil {
+		return nil, err
+	}
+	return rb, nil
+}
+
+// CreateRoleBindingFromFile creates a role binding from a manifest file in the given namespace.
+func (test *Test) CreateRoleBindingFromFile(namespace string, manifestPath string) *rbacv1.RoleBinding {
+	rb, err := test.createRoleBindingFromFile(namespace, manifestPath)
+	test.err(err)
+	return rb
+}
+
+func (test *Test) deleteRoleBinding(rb *rbacv1.RoleBinding) error {
+	test.Debugf("deleting role binding %s", rb.Name)
+
+	if err := test.kubeClient.RbacV1().RoleBindings(rb.Namespace).Delete(context.TODO(), rb.Name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("deleting role binding %s failed: %w", rb.Name, err)
+	}
+	return nil
+}
+
+// DeleteRoleBinding deletes a role binding.
+func (test *Test) DeleteRoleBinding(rb *rbacv1.RoleBinding) {
+	err := test.deleteRoleBinding(rb)
+	test.err(err)
+}
+
+// GetRoleBinding returns a RoleBinding object if it exists or error.
+func (test *Test) GetRoleBinding(ns, name string) (*rbacv1.RoleBinding, error) {
+	rb, err := test.kubeClient.RbacV1().RoleBindings(ns).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return rb, nil
+}
+
+func (test *Test) waitForRoleBindingReady(ns, name string, timeout time.Duration) error {
+	test.Debugf("waiting for role binding %s to be ready", name)
+
+	return wait.Poll(rbacPollInterval, timeout, func() (bool, error) {
+		_, err := test.GetRoleBinding(ns, name)
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+// WaitForRoleBindingReady waits until RoleBinding is created, otherwise times out.
+func (test *Test) WaitForRoleBindingReady(rb *rbacv1.RoleBinding, timeout time.Duration) {
+	err := test.waitForRoleBindingReady(rb.Namespace, rb.Name, timeout)
+	test.err(err)
+}
@@ -0,0 +1,212 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// scaleClient reads and writes the scale subresource of an object.
+type scaleClient struct {
+	name   string
+	get    func() (*autoscalingv1.Scale, error)
+	update func(*autoscalingv1.Scale) (*autoscalingv1.Scale, error)
+}
+
+// objectScaleClient returns a scaleClient for one of the kinds managed by the
+// harness.
+func (test *Test) objectScaleClient(obj runtime.Object) (*scaleClient, error) {
+	if _, err := test.request("GET", obj); err != nil {
+		return nil, err
+	}
+
+	return &scaleClient{
+		name: objectName(obj),
+		get: func() (*autoscalingv1.Scale, error) {
+			req, _ := test.request("GET", obj)
+			scale := &autoscalingv1.Scale{}
+			err := req.SubResource("scale").Do(context.TODO()).Into(scale)
+			return scale, err
+		},
+		update: func(scale *autoscalingv1.Scale) (*autoscalingv1.Scale, error) {
+			req, _ := test.request("PUT", obj)
+			updated := &autoscalingv1.Scale{}
+			err := req.SubResource("scale").Body(scale).Do(context.TODO()).Into(updated)
+			return updated, err
+		},
+	}, nil
+}
+
+// resourceScaleClient returns a scaleClient for any resource with a scale
+// subresource, including custom resources.
+func (test *Test) resourceScaleClient(gvr schema.GroupVersionResource, namespace, name string) (*scaleClient, error) {
+	client, err := dynamic.NewForConfig(test.restConfig)
+	if err != nil {
+		return nil, err
+	}
+	resource := client.Resource(gvr).Namespace(namespace)
+
+	fromUnstructured := func(u *unstructured.Unstructured) (*autoscalingv1.Scale, error) {
+		scale := &autoscalingv1.Scale{}
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, scale)
+		return scale, err
+	}
+
+	return &scaleClient{
+		name: name,
+		get: func() (*autoscalingv1.Scale, error) {
+			u, err := resource.Get(context.TODO(), name, metav1.GetOptions{}, "scale")
+			if err != nil {
+				return nil, err
+			}
+			return fromUnstructured(u)
+		},
+		update: func(scale *autoscalingv1.Scale) (*autoscalingv1.Scale, error) {
+			obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(scale)
+			if err != nil {
+				return nil, err
+			}
+			u := &unstructured.Unstructured{Object: obj}
+			u.SetAPIVersion(autoscalingv1.SchemeGroupVersion.String())
+			u.SetKind("Scale")
+			u, err = resource.Update(context.TODO(), u, metav1.UpdateOptions{}, "scale")
+			if err != nil {
+				return nil, err
+			}
+			return fromUnstructured(u)
+		},
+	}, nil
+}
+
+// scale sets the number of replicas through the scale subresource and returns
+// the updated scale.
+func (test *Test) scale(client *scaleClient, replicas int32) (*autoscalingv1.Scale, error) {
+	test.Debugf("scaling %s to %d replicas", client.name, replicas)
+
+	var updated *autoscalingv1.Scale
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scale, err := client.get()
+		if err != nil {
+			return err
+		}
+		scale.Spec.Replicas = replicas
+		updated, err = client.update(scale)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scaling %s failed: %w", client.name, err)
+	}
+	return updated, nil
+}
+
+// scaledPodsReady returns whether pods is made of exactly replicas pods, all
+// ready. Terminating pods are counted so surplus pods have to be gone.
+func (test *Test) scaledPodsReady(pods []v1.Pod, replicas int32) (bool, error) {
+	if len(pods) != int(replicas) {
+		return false, nil
+	}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			return false, nil
+		}
+		ready, err := test.PodReady(pod)
+		if err != nil || !ready {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// waitForScaled waits until the pods selected by the scale status selector are
+// exactly the desired number of replicas and are all ready.
+func (test *Test) waitForScaled(namespace string, client *scaleClient, replicas int32, timeout time.Duration) error {
+	test.Debugf("waiting for %s to have %d ready replicas", client.name, replicas)
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		scale, err := client.get()
+		if err != nil {
+			return false, err
+		}
+		if scale.Status.Selector == "" {
+			return false, fmt.Errorf("%s: the scale subresource doesn't expose a label selector", client.name)
+		}
+		pl, err := test.listPods(namespace, metav1.ListOptions{
+			LabelSelector: scale.Status.Selector,
+		})
+		if err != nil {
+			return false, err
+		}
+		return test.scaledPodsReady(pl.Items, replicas)
+	})
+}
+
+func (test *Test) scaleAndWait(namespace string, client *scaleClient, replicas int32, timeout time.Duration) error {
+	// Don't leave the object scaled when nothing would reconcile it.
+	if err := test.needsControllers("Scale"); err != nil {
+		return err
+	}
+	if _, err := test.scale(client, replicas); err != nil {
+		return err
+	}
+	return test.waitForScaled(namespace, client, replicas, timeout)
+}
+
+func (test *Test) scaleObject(obj runtime.Object, replicas int32, timeout time.Duration) error {
+	client, err := test.objectScaleClient(obj)
+	if err != nil {
+		return err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	return test.scaleAndWait(accessor.GetNamespace(), client, replicas, timeout)
+}
+
+// Scale sets the number of replicas of obj through the scale subresource and
+// waits until exactly that number of pods are ready and the surplus pods, if
+// any, are gone. obj can be any of the kinds the harness manages with a scale
+// subresource, eg. a Deployment, a StatefulSet or a ReplicaSet.
+func (test *Test) Scale(obj runtime.Object, replicas int32, timeout time.Duration) {
+	test.err(test.scaleObject(obj, replicas, timeout))
+}
+
+// ScaleDeployment sets the number of replicas of a deployment and waits until
+// exactly that number of pods are ready and the surplus pods are gone.
+func (test *Test) ScaleDeployment(d *appsv1.Deployment, replicas int32, timeout time.Duration) {
+	test.err(test.scaleObject(d, replicas, timeout))
+}
+
+// ScaleStatefulSet sets the number of replicas of a statefulset and waits
+// until exactly that number of pods are ready and the surplus pods are gone.
+func (test *Test) ScaleStatefulSet(s *appsv1.StatefulSet, replicas int32, timeout time.Duration) {
+	test.err(test.scaleObject(s, replicas, timeout))
+}
+
+func (test *Test) scaleResource(gvr schema.GroupVersionResource, namespace, name string, replicas int32, timeout time.Duration) error {
+	client, err := test.resourceScaleClient(gvr, namespace, name)
+	if err != nil {
+		return err
+	}
+	return test.scaleAndWait(namespace, client, replicas, timeout)
+}
+
+// ScaleResource is Scale for any resource with a scale subresource, including
+// custom resources. The scale subresource needs to expose a label selector
+// (the labelSelectorPath of the CRD scale subresource) for the harness to find
+// the pods.
+func (test *Test) ScaleResource(gvr schema.GroupVersionResource, namespace, name string, replicas int32, timeout time.Duration) {
+	test.err(test.scaleResource(gvr, namespace, name, replicas, timeout))
+}
@@ -0,0 +1,69 @@
+package harness
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScaledPodsReady(t *testing.T) {
+	readyPod := func() v1.Pod {
+		return v1.Pod{
+			Status: v1.PodStatus{
+				Phase:      v1.PodRunning,
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+			},
+		}
+	}
+	notReady := readyPod()
+	notReady.Status.Conditions[0].Status = v1.ConditionFalse
+	terminating := readyPod()
+	terminating.DeletionTimestamp = &metav1.Time{}
+
+	tests := []struct {
+		name     string
+		pods     []v1.Pod
+		replicas int32
+		expected bool
+	}{
+		{"scaled down to zero", nil, 0, true},
+		{"ready", []v1.Pod{readyPod(), readyPod()}, 2, true},
+		{"missing pod", []v1.Pod{readyPod()}, 2, false},
+		{"surplus pod", []v1.Pod{readyPod(), readyPod(), readyPod()}, 2, false},
+		{"not ready", []v1.Pod{readyPod(), notReady}, 2, false},
+		{"terminating", []v1.Pod{readyPod(), terminating}, 2, false},
+	}
+
+	test := &Test{}
+	for _, tt := range tests {
+		ready, err := test.scaledPodsReady(tt.pods, tt.replicas)
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.expected, ready, tt.name)
+	}
+}
+
+func TestScaleNoControllers(t *testing.T) {
+	test := &Test{harness: &Harness{options: Options{ControlPlaneOnly: true}}}
+
+	updated := false
+	client := &scaleClient{
+		name: "deployment app",
+		get: func() (*autoscalingv1.Scale, error) {
+			return &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 1}}, nil
+		},
+		update: func(scale *autoscalingv1.Scale) (*autoscalingv1.Scale, error) {
+			updated = true
+			return scale, nil
+		},
+	}
+
+	err := test.scaleAndWait("ns", client, 3, time.Second)
+	var noControllers *NoControllersError
+	assert.True(t, errors.As(err, &noControllers), "unexpected error: %v", err)
+	assert.False(t, updated, "the object has been scaled")
+}
@@ -13,7 +13,7 @@ import (
 
 func (test *Test) createSecret(namespace string, secret *v1.Secret) error {
 	secret.Namespace = namespace
-	if _, err := test.harness.kubeClient.CoreV1().Secrets(namespace).Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
+	if _, err := test.kubeClient.CoreV1().Secrets(namespace).Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
 		return fmt.Errorf("failed to create secret %s: %w", secret.Name, err)
 	}
 	return nil
@@ -66,7 +66,7 @@ func (test *Test) CreateSecretFromFile(namespace string, manifestPath string) *v
 }
 
 func (test *Test) deleteSecret(secret *v1.Secret) error {
-	if err := test.harness.kubeClient.CoreV1().Secrets(secret.Namespace).Delete(context.TODO(), secret.Name, metav1.DeleteOptions{}); err != nil {
+	if err := test.kubeClient.CoreV1().Secrets(secret.Namespace).Delete(context.TODO(), secret.Name, metav1.DeleteOptions{}); err != nil {
 		return fmt.Errorf("deleting secret %s failed: %w", secret.Name, err)
 	}
 	return nil
@@ -80,7 +80,7 @@ func (test *Test) DeleteSecret(secret *v1.Secret) {
 
 // GetSecret returns a Secret object if it exists or error.
 func (test *Test) GetSecret(ns, name string) (*v1.Secret, error) {
-	s, err := test.harness.kubeClient.CoreV1().Secrets(ns).Get(context.TODO(), name, metav1.GetOptions{})
+	s, err := test.kubeClient.CoreV1().Secrets(ns).Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
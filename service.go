@@ -15,7 +15,7 @@ func (test *Test) createService(namespace string, service *v1.Service) error {
 	test.Debugf("creating service %s", service.Name)
 
 	service.Namespace = namespace
-	if _, err := test.harness.kubeClient.CoreV1().Services(namespace).Create(context.TODO(), service, metav1.CreateOptions{}); err != nil {
+	if _, err := test.kubeClient.CoreV1().Services(namespace).Create(context.TODO(), service, metav1.CreateOptions{}); err != nil {
 		return fmt.Errorf("failed to create service %s: %w", service.Name, err)
 	}
 	return nil
@@ -33,7 +33,7 @@ func (test *Test) getService(namespace, name string) (*v1.Service, error) {
 		err     error
 	)
 
-	if service, err = test.harness.kubeClient.CoreV1().Services(namespace).Get(context.TODO(), name, metav1.GetOptions{}); err != nil {
+	if service, err = test.kubeClient.CoreV1().Services(namespace).Get(context.TODO(), name, metav1.GetOptions{}); err != nil {
 		return nil, fmt.Errorf("failed to get service %s: %w", name, err)
 	}
 	return service, nil
@@ -89,6 +89,10 @@ func (test *Test) CreateServiceFromFile(namespace string, manifestPath string) *
 func (test *Test) waitForServiceReady(service *v1.Service) error {
 	test.Debugf("waiting for service %s to be ready", service.Name)
 
+	if err := test.needsControllers("WaitForServiceReady"); err != nil {
+		return err
+	}
+
 	err := wait.Poll(time.Second, time.Minute*5, func() (bool, error) {
 		endpoints, err := test.getEndpoints(service.Namespace, service.Name)
 		if err != nil {
@@ -110,7 +114,7 @@ func (test *Test) WaitForServiceReady(service *v1.Service) {
 func (test *Test) updateService(service *v1.Service) error {
 	test.Debugf("updating service %s", service.Name)
 
-	if _, err := test.harness.kubeClient.CoreV1().Services(service.Namespace).Update(context.TODO(), service, metav1.UpdateOptions{}); err != nil {
+	if _, err := test.kubeClient.CoreV1().Services(service.Namespace).Update(context.TODO(), service, metav1.UpdateOptions{}); err != nil {
 		return fmt.Errorf("updating service %v failed: %w", service.Name, err)
 	}
 	return nil
@@ -125,7 +129,7 @@ func (test *Test) UpdateService(service *v1.Service) {
 func (test *Test) deleteService(service *v1.Service) error {
 	test.Debugf("deleting service %s", service.Name)
 
-	if err := test.harness.kubeClient.CoreV1().Services(service.Namespace).Delete(context.TODO(), service.Name, metav1.DeleteOptions{}); err != nil {
+	if err := test.kubeClient.CoreV1().Services(service.Namespace).Delete(context.TODO(), service.Name, metav1.DeleteOptions{}); err != nil {
 		return fmt.Errorf("deleting service %v failed: %w", service.Name, err)
 	}
 	return nil
@@ -160,7 +164,7 @@ func (test *Test) WaitForServiceDeleted(service *v1.Service) {
 }
 
 func (test *Test) getEndpoints(namespace, serviceName string) (*v1.Endpoints, error) {
-	endpoints, err := test.harness.kubeClient.CoreV1().Endpoints(namespace).Get(context.TODO(), serviceName, metav1.GetOptions{})
+	endpoints, err := test.kubeClient.CoreV1().Endpoints(namespace).Get(context.TODO(), serviceName, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to request endpoints for service %s: %w", serviceName, err)
 	}
@@ -15,7 +15,7 @@ func (test *Test) createServiceAccount(namespace string, serviceAccount *v1.Serv
 	test.Debugf("creating serviceaccount %s", serviceAccount.Name)
 
 	serviceAccount.Namespace = namespace
-	if _, err := test.harness.kubeClient.CoreV1().ServiceAccounts(namespace).Create(context.TODO(), serviceAccount, metav1.CreateOptions{}); err != nil {
+	if _, err := test.kubeClient.CoreV1().ServiceAccounts(namespace).Create(context.TODO(), serviceAccount, metav1.CreateOptions{}); err != nil {
 		return fmt.Errorf("failed to create ServiceAccount %s: %w", serviceAccount.Name, err)
 	}
 	return nil
@@ -70,7 +70,7 @@ func (test *Test) CreateServiceAccountFromFile(namespace string, manifestPath st
 func (test *Test) deleteServiceAccount(serviceAccount *v1.ServiceAccount) error {
 	test.Debugf("deleting serviceaccount %s ", serviceAccount.Name)
 
-	if err := test.harness.kubeClient.CoreV1().ServiceAccounts(serviceAccount.Namespace).Delete(context.TODO(), serviceAccount.Name, metav1.DeleteOptions{}); err != nil {
+	if err := test.kubeClient.CoreV1().ServiceAccounts(serviceAccount.Namespace).Delete(context.TODO(), serviceAccount.Name, metav1.DeleteOptions{}); err != nil {
 		return fmt.Errorf("deleting ServiceAccount %s failed: %w", serviceAccount.Name, err)
 	}
 	return nil
@@ -84,7 +84,7 @@ func (test *Test) DeleteServiceAccount(serviceAccount *v1.ServiceAccount) {
 
 // GetServiceAccount returns a ServiceAccount object if it exists or error.
 func (test *Test) GetServiceAccount(namespace, name string) (*v1.ServiceAccount, error) {
-	return test.harness.kubeClient.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	return test.kubeClient.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 }
 
 func (test *Test) waitForServiceAccountReady(serviceAccount *v1.ServiceAccount) error {
@@ -0,0 +1,349 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// createStatefulSet creates a statefulset in the given namespace.
+func (test *Test) createStatefulSet(namespace string, s *appsv1.StatefulSet) error {
+	test.Debugf("creating statefulset %s", s.Name)
+
+	s.Namespace = namespace
+	_, err := test.kubeClient.AppsV1().StatefulSets(namespace).Create(context.TODO(), s, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create statefulset %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+// CreateStatefulSet creates a statefulset in the given namespace.
+func (test *Test) CreateStatefulSet(namespace string, s *appsv1.StatefulSet) {
+	err := test.createStatefulSet(namespace, s)
+	test.err(err)
+}
+
+func (test *Test) loadStatefulSet(manifestPath string) (*appsv1.StatefulSet, error) {
+	manifest, err := test.harness.openManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	s := appsv1.StatefulSet{}
+	if err := yaml.NewYAMLOrJSONDecoder(manifest, 100).Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to decode statefulset %s: %w", manifestPath, err)
+	}
+
+	return &s, nil
+}
+
+// LoadStatefulSet loads a statefulset from a YAML manifest. The path to the
+// manifest is relative to Harness.ManifestDirectory.
+func (test *Test) LoadStatefulSet(manifestPath string) *appsv1.StatefulSet {
+	s, err := test.loadStatefulSet(manifestPath)
+	test.err(err)
+	return s
+}
+
+func (test *Test) createStatefulSetFromFile(namespace string, manifestPath string) (*appsv1.StatefulSet, error) {
+	s, err := test.loadStatefulSet(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	err = test.createStatefulSet(namespace, s)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// CreateStatefulSetFromFile creates a statefulset from a manifest file in the given namespace.
+func (test *Test) CreateStatefulSetFromFile(namespace string, manifestPath string) *appsv1.StatefulSet {
+	s, err := test.createStatefulSetFromFile(namespace, manifestPath)
+	test.err(err)
+	return s
+}
+
+// GetStatefulSet returns statefulset if it exists or error if it doesn't.
+func (test *Test) GetStatefulSet(ns, name string) (*appsv1.StatefulSet, error) {
+	s, err := test.kubeClient.AppsV1().StatefulSets(ns).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func statefulSetReplicas(s *appsv1.StatefulSet) int {
+	if s.Spec.Replicas == nil {
+		return 1
+	}
+	return int(*s.Spec.Replicas)
+}
+
+// statefulSetPodOrdinal returns the ordinal of a pod created by s or -1 if the
+// pod name doesn't follow the statefulset naming scheme.
+func statefulSetPodOrdinal(s *appsv1.StatefulSet, pod *v1.Pod) int {
+	prefix := s.Name + "-"
+	if !strings.HasPrefix(pod.Name, prefix) {
+		return -1
+	}
+	ordinal, err := strconv.Atoi(strings.TrimPrefix(pod.Name, prefix))
+	if err != nil || ordinal < 0 {
+		return -1
+	}
+	return ordinal
+}
+
+func (test *Test) listPodsFromStatefulSet(s *appsv1.StatefulSet) (*v1.PodList, error) {
+	selector, err := selectorToString(s.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	pl, err := test.listPods(s.Namespace, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := pl.Items[:0]
+	for _, pod := range pl.Items {
+		if statefulSetPodOrdinal(s, &pod) >= 0 {
+			items = append(items, pod)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return statefulSetPodOrdinal(s, &items[i]) < statefulSetPodOrdinal(s, &items[j])
+	})
+	pl.Items = items
+
+	return pl, nil
+}
+
+// ListPodsFromStatefulSet returns the list of pods created by a statefulset,
+// sorted by ordinal.
+func (test *Test) ListPodsFromStatefulSet(s *appsv1.StatefulSet) *v1.PodList {
+	pl, err := test.listPodsFromStatefulSet(s)
+	test.err(err)
+	return pl
+}
+
+// GetStatefulSetPod returns the pod of a statefulset with the given ordinal if
+// it exists or error if it doesn't.
+func (test *Test) GetStatefulSetPod(s *appsv1.StatefulSet, ordinal int) (*v1.Pod, error) {
+	name := fmt.Sprintf("%s-%d", s.Name, ordinal)
+	return test.kubeClient.CoreV1().Pods(s.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+// waitForStatefulSetReady waits until all replica pods are running and ready.
+func (test *Test) waitForStatefulSetReady(s *appsv1.StatefulSet, timeout time.Duration) error {
+	test.Debugf("waiting for statefulset %s to be ready", s.Name)
+
+	if err := test.needsControllers("WaitForStatefulSetReady"); err != nil {
+		return err
+	}
+
+	numReady := -1
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		current, err := test.GetStatefulSet(s.Namespace, s.Name)
+		if err != nil {
+			return false, err
+		}
+		if current.Status.ObservedGeneration < current.Generation {
+			return false, nil
+		}
+
+		pl, err := test.listPodsFromStatefulSet(current)
+		if err != nil {
+			return false, err
+		}
+
+		// Pods are started in order: count the ready pods until we find the first
+		// ordinal that isn't ready.
+		replicas := statefulSetReplicas(current)
+		ready := 0
+		for _, pod := range pl.Items {
+			if statefulSetPodOrdinal(current, &pod) != ready {
+				break
+			}
+			isReady, err := test.PodReady(pod)
+			if err != nil {
+				return false, fmt.Errorf("statefulset %s: pod %s: %w", s.Name, pod.Name, err)
+			}
+			if !isReady {
+				break
+			}
+			ready++
+		}
+
+		if numReady != ready {
+			numReady = ready
+			test.Debugf("%s ordinals ready: %d/%d", s.Name, numReady, replicas)
+		}
+
+		return ready == replicas && int(current.Status.ReadyReplicas) == replicas, nil
+	})
+}
+
+// WaitForStatefulSetReady waits until all replica pods are running and ready,
+// starting from ordinal 0.
+func (test *Test) WaitForStatefulSetReady(s *appsv1.StatefulSet, timeout time.Duration) {
+	err := test.waitForStatefulSetReady(s, timeout)
+	test.err(err)
+}
+
+// statefulSetRolledOut returns whether a rolling update of s has completed.
+// This follows what kubectl rollout status does.
+func statefulSetRolledOut(s *appsv1.StatefulSet) (bool, error) {
+	if s.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return false, fmt.Errorf("statefulset %s: rollout status is only available for the %s strategy", s.Name, appsv1.RollingUpdateStatefulSetStrategyType)
+	}
+	if s.Status.ObservedGeneration < s.Generation {
+		return false, nil
+	}
+
+	replicas := statefulSetReplicas(s)
+	if int(s.Status.ReadyReplicas) < replicas {
+		return false, nil
+	}
+	if ru := s.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil && *ru.Partition > 0 {
+		return int(s.Status.UpdatedReplicas) >= replicas-int(*ru.Partition), nil
+	}
+	return s.Status.UpdateRevision == s.Status.CurrentRevision, nil
+}
+
+// waitForStatefulSetRolledOut waits until a rolling update has completed.
+func (test *Test) waitForStatefulSetRolledOut(s *appsv1.StatefulSet, timeout time.Duration) error {
+	test.Debugf("waiting for statefulset %s to be rolled out", s.Name)
+
+	if err := test.needsControllers("WaitForStatefulSetRolledOut"); err != nil {
+		return err
+	}
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		current, err := test.GetStatefulSet(s.Namespace, s.Name)
+		if err != nil {
+			return false, err
+		}
+		return statefulSetRolledOut(current)
+	})
+}
+
+// WaitForStatefulSetRolledOut waits until a rolling update has completed, ie.
+// the current revision has become the update revision and all replicas are
+// ready. When a partition is used, only the ordinals above the partition need
+// to have been updated.
+func (test *Test) WaitForStatefulSetRolledOut(s *appsv1.StatefulSet, timeout time.Duration) {
+	err := test.waitForStatefulSetRolledOut(s, timeout)
+	test.err(err)
+}
+
+// deleteStatefulSet deletes a statefulset in the given namespace.
+func (test *Test) deleteStatefulSet(s *appsv1.StatefulSet) error {
+	test.Debugf("deleting statefulset %s ", s.Name)
+
+	if err := test.kubeClient.AppsV1().StatefulSets(s.Namespace).Delete(context.TODO(), s.Name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("deleting statefulset %s failed: %w", s.Name, err)
+	}
+	return nil
+}
+
+// DeleteStatefulSet deletes a statefulset in the given namespace.
+func (test *Test) DeleteStatefulSet(s *appsv1.StatefulSet) {
+	test.err(test.deleteStatefulSet(s))
+}
+
+// waitForStatefulSetDeleted waits until a deleted statefulset has disappeared from the cluster.
+func (test *Test) waitForStatefulSetDeleted(s *appsv1.StatefulSet, timeout time.Duration) error {
+	test.Debugf("waiting for statefulset %s to be deleted", s.Name)
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		_, err := test.GetStatefulSet(s.Namespace, s.Name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+
+			return false, err
+		}
+
+		return false, nil
+	})
+}
+
+// WaitForStatefulSetDeleted waits until a deleted statefulset has disappeared from the cluster.
+func (test *Test) WaitForStatefulSetDeleted(s *appsv1.StatefulSet, timeout time.Duration) {
+	test.err(test.waitForStatefulSetDeleted(s, timeout))
+}
+
+// isStatefulSetPVC returns whether pvc has been created from one of the volume
+// claim templates of s.
+func isStatefulSetPVC(s *appsv1.StatefulSet, pvc *v1.PersistentVolumeClaim) bool {
+	for _, tpl := range s.Spec.VolumeClaimTemplates {
+		prefix := tpl.Name + "-" + s.Name + "-"
+		if !strings.HasPrefix(pvc.Name, prefix) {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(pvc.Name, prefix)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isOwnedBy returns whether obj has an owner reference to the object of the
+// given kind and name.
+func isOwnedBy(obj metav1.Object, kind, name string) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Kind == kind && ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForStatefulSetPVCsDeleted waits until the PVCs of a deleted statefulset
+// have been garbage collected.
+func (test *Test) waitForStatefulSetPVCsDeleted(s *appsv1.StatefulSet, timeout time.Duration) error {
+	test.Debugf("waiting for the PVCs of statefulset %s to be deleted", s.Name)
+
+	if err := test.needsControllers("WaitForStatefulSetPVCsDeleted"); err != nil {
+		return err
+	}
+
+	return wait.Poll(time.Second, timeout, func() (bool, error) {
+		pvcs, err := test.kubeClient.CoreV1().PersistentVolumeClaims(s.Namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		for i := range pvcs.Items {
+			pvc := &pvcs.Items[i]
+			if isStatefulSetPVC(s, pvc) && isOwnedBy(pvc, "StatefulSet", s.Name) {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// WaitForStatefulSetPVCsDeleted waits until the PVCs of a deleted statefulset
+// have been cleaned up according to its PVC retention policy. When the policy
+// is to delete PVCs with the statefulset, the statefulset controller makes the
+// statefulset own them and this function waits until they have been garbage
+// collected. PVCs that are retained are left untouched and not waited for.
+func (test *Test) WaitForStatefulSetPVCsDeleted(s *appsv1.StatefulSet, timeout time.Duration) {
+	test.err(test.waitForStatefulSetPVCsDeleted(s, timeout))
+}
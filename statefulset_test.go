@@ -0,0 +1,99 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestStatefulSetPodOrdinal(t *testing.T) {
+	s := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "web"}}
+
+	tests := []struct {
+		pod      string
+		expected int
+	}{
+		{"web-0", 0},
+		{"web-12", 12},
+		{"web-abc", -1},
+		{"web", -1},
+		{"db-0", -1},
+	}
+
+	for _, test := range tests {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: test.pod}}
+		assert.Equal(t, test.expected, statefulSetPodOrdinal(s, pod), test.pod)
+	}
+}
+
+func TestIsStatefulSetPVC(t *testing.T) {
+	s := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Spec: appsv1.StatefulSetSpec{
+			VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+				{ObjectMeta: metav1.ObjectMeta{Name: "data"}},
+			},
+		},
+	}
+
+	for name, expected := range map[string]bool{
+		"data-web-0":   true,
+		"data-web-3":   true,
+		"data-web":     false,
+		"logs-web-0":   false,
+		"data-web-foo": false,
+	} {
+		pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		assert.Equal(t, expected, isStatefulSetPVC(s, pvc), name)
+	}
+}
+
+func TestStatefulSetRolledOut(t *testing.T) {
+	newStatefulSet := func(status appsv1.StatefulSetStatus) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Generation: 2},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas: int32Ptr(3),
+				UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+					Type: appsv1.RollingUpdateStatefulSetStrategyType,
+				},
+			},
+			Status: status,
+		}
+	}
+
+	tests := []struct {
+		status   appsv1.StatefulSetStatus
+		expected bool
+	}{
+		{appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, CurrentRevision: "a", UpdateRevision: "a"}, false},
+		{appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 2, CurrentRevision: "b", UpdateRevision: "b"}, false},
+		{appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, CurrentRevision: "a", UpdateRevision: "b"}, false},
+		{appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, CurrentRevision: "b", UpdateRevision: "b"}, true},
+	}
+
+	for _, test := range tests {
+		done, err := statefulSetRolledOut(newStatefulSet(test.status))
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, done)
+	}
+
+	// Partitioned rollout.
+	s := newStatefulSet(appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 1, CurrentRevision: "a", UpdateRevision: "b"})
+	s.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{Partition: int32Ptr(2)}
+	done, err := statefulSetRolledOut(s)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	// OnDelete doesn't support rollout status.
+	s.Spec.UpdateStrategy.Type = appsv1.OnDeleteStatefulSetStrategyType
+	_, err = statefulSetRolledOut(s)
+	assert.Error(t, err)
+}
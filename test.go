@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -15,6 +16,8 @@ import (
 
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 
 	"golang.org/x/sync/errgroup"
 )
@@ -31,11 +34,37 @@ type Test struct {
 
 	nextObjectID uint64
 	harness      *Harness
+	kubeClient   kubernetes.Interface
+	restConfig   *rest.Config
 	t            testing.T
 	logger       logger.Logger
 	inError      bool
 	namespaces   []string // List of namespaces created by the test
 	cleanUpFns   []finalizer
+	teardownFns  []finalizer
+	retries      int64 // Number of API calls retried, see RetryPolicy
+
+	// allowPodRestarts disables the pod restarts check of the test, see
+	// Test.AllowPodRestarts.
+	allowPodRestarts bool
+
+	// parent is the test a view has been created from, see Test.As. Views
+	// share the objects, finalizers and state of their parent.
+	parent *Test
+	// user is the identity impersonated by a view.
+	user string
+	// catchForbidden is set while running Test.Forbidden, denied is the first
+	// ForbiddenError reported while it's set.
+	catchForbidden bool
+	denied         *ForbiddenError
+
+	// clusterName is the name of the cluster a cluster view targets, see
+	// Test.Cluster. home is the test a cluster view has been created from.
+	// Contrary to other views, cluster views have their own namespaces and
+	// finalizers, run when home is closed.
+	clusterName string
+	home        *Test
+	clusters    map[string]*Test
 }
 
 func testLogger(l logger.Logger, t testing.T) logger.Logger {
@@ -45,6 +74,8 @@ func testLogger(l logger.Logger, t testing.T) logger.Logger {
 // NewTest creates a new test. Call Close() to free kubernetes resources
 // allocated during the test.
 func (h *Harness) NewTest(t testing.T) *Test {
+	t.Helper()
+
 	// TestCtx is used among others for namespace names where '/' is forbidden
 	prefix := strings.TrimPrefix(
 		strings.Replace(
@@ -65,6 +96,10 @@ func (h *Harness) NewTest(t testing.T) *Test {
 	}
 	test.Namespace = test.getObjID("ns")
 
+	var err error
+	test.restConfig, test.kubeClient, err = test.withRetries(h.restConfig)
+	test.err(err)
+
 	test.Infof("using API server %s", h.apiServer)
 
 	return test
@@ -73,7 +108,7 @@ func (h *Harness) NewTest(t testing.T) *Test {
 // getObjID returns an unique ID that can be used to name kubernetes objects. We
 // also encode the object type in the name.
 func (t *Test) getObjID(objectType string) string {
-	id := atomic.AddUint64(&t.nextObjectID, 1)
+	id := atomic.AddUint64(&t.root().nextObjectID, 1)
 	return t.ID + "-" + objectType + "-" + fmt.Sprintf("%d", id)
 }
 
@@ -146,12 +181,19 @@ func (t *Test) DumpNamespace(w io.Writer, ns string) {
 }
 
 // DumpTestState writes to w information about the objects created by the test.
+// For a cluster view, see Test.Cluster, this is the objects created in that
+// cluster.
 func (t *Test) DumpTestState(w io.Writer) {
+	if t.clusterName != "" {
+		fmt.Fprintf(w, "\n=== cluster %s\n", t.clusterName)
+	}
+
 	// kube-system is interesting because it has pods that could make tests fail
 	// (eg. kube-dns)
-	namespaces := make([]string, len(t.namespaces)+1)
+	r := t.root()
+	namespaces := make([]string, len(r.namespaces)+1)
 	namespaces[0] = "kube-system"
-	copy(namespaces[1:], t.namespaces)
+	copy(namespaces[1:], r.namespaces)
 
 	for _, ns := range namespaces {
 		t.DumpNamespace(w, ns)
@@ -164,16 +206,92 @@ func (t *Test) dumpTestState() {
 }
 
 func (t *Test) fatal(args ...interface{}) {
+	t.t.Helper()
 	t.t.Fatal(args...)
 }
 
-// Close frees all kubernetes resources allocated during the test.
+// teardown releases the local resources held by the test, eg. port forwards.
+// Contrary to finalizers, teardown functions are always run.
+func (t *Test) teardown() {
+	for i := len(t.teardownFns) - 1; i >= 0; i-- {
+		if err := t.teardownFns[i](); err != nil {
+			t.t.Error(err)
+		}
+	}
+	t.teardownFns = nil
+}
+
+// AllowPodRestarts lets the containers of the pods in the test namespaces
+// restart without failing the test when it's closed, eg. for a test expecting
+// a job to fail. It's the per-test version of Options.AllowPodRestarts and
+// applies to all the clusters used by the test.
+func (t *Test) AllowPodRestarts() {
+	t.homeTest().allowPodRestarts = true
+}
+
+// checkPodRestarts flags the pods of the test namespaces that have restarted.
+func (t *Test) checkPodRestarts() {
+	if t.harness.options.AllowPodRestarts || t.homeTest().allowPodRestarts {
+		return
+	}
+
+	for _, ns := range t.namespaces {
+		if err := t.assertNoRestarts(ns, metav1.ListOptions{}); err != nil {
+			t.inError = true
+			t.t.Errorf("namespace %s: %v", ns, err)
+		}
+	}
+}
+
+// clusterViews returns the cluster views of the test, sorted by cluster name.
+func (t *Test) clusterViews() []*Test {
+	names := make([]string, 0, len(t.clusters))
+	for name := range t.clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	views := make([]*Test, len(names))
+	for i, name := range names {
+		views[i] = t.clusters[name]
+	}
+	return views
+}
+
+// Close frees all kubernetes resources allocated during the test, in all the
+// clusters it has used. Closing a view, see Test.As and Test.Cluster, does
+// nothing: the resources are freed when the test the view has been created
+// from is closed.
 func (t *Test) Close() {
+	if t.parent != nil || t.home != nil {
+		return
+	}
+
 	// We're being called while panicking, don't cleanup!
 	if r := recover(); r != nil {
 		t.dumpTestState()
+		t.teardown()
+		for _, view := range t.clusterViews() {
+			view.dumpTestState()
+			view.teardown()
+		}
 		panic(r)
 	}
+
+	for _, view := range t.clusterViews() {
+		view.close()
+	}
+	t.close()
+	t.reportRetries()
+}
+
+func (t *Test) close() {
+	t.teardown()
+
+	if !t.t.Failed() && !t.inError {
+		t.checkPodRestarts()
+	}
+
 	if (t.t.Failed()) || t.inError {
 		t.dumpTestState()
 		return
@@ -190,31 +308,56 @@ func (t *Test) Close() {
 	}
 
 	if err := eg.Wait(); err != nil {
-		t.fatal(err)
+		t.t.Error(err)
 	}
 }
 
 func (t *Test) err(err error) {
 	if err != nil {
-		t.inError = true
+		err = t.forbidden(err)
+		if t.catch(err) {
+			return
+		}
+		t.t.Helper()
+		t.root().inError = true
 		t.fatal(err)
 	}
 }
 
+// root returns the test views have been created from.
+func (t *Test) root() *Test {
+	for t.parent != nil {
+		t = t.parent
+	}
+	return t
+}
+
 func (t *Test) addNamespace(ns string) {
-	t.namespaces = append(t.namespaces, ns)
+	r := t.root()
+	r.namespaces = append(r.namespaces, ns)
 }
 
 func (t *Test) removeNamespace(ns string) {
-	for i, s := range t.namespaces {
+	r := t.root()
+	for i, s := range r.namespaces {
 		if s == ns {
-			t.namespaces = append(t.namespaces[:i], t.namespaces[i+1:]...)
+			r.namespaces = append(r.namespaces[:i], r.namespaces[i+1:]...)
 		}
 	}
 }
 
+// addFinalizer registers fn to be run when the test is closed. Finalizers and
+// teardown functions are shared with the views of the test and should make
+// their API calls through t.root(): a view created with As may not be allowed
+// to clean up what it has created.
 func (t *Test) addFinalizer(fn finalizer) {
-	t.cleanUpFns = append(t.cleanUpFns, fn)
+	r := t.root()
+	r.cleanUpFns = append(r.cleanUpFns, fn)
+}
+
+func (t *Test) addTeardown(fn finalizer) {
+	r := t.root()
+	r.teardownFns = append(r.teardownFns, fn)
 }
 
 // Debug prints a debug message.
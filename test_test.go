@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestAddRemoveNamespace(t *testing.T) {
@@ -31,3 +33,42 @@ func TestAddRemoveNamespace(t *testing.T) {
 	test.removeNamespace("ns2")
 	assert.Equal(t, len(test.namespaces), 0)
 }
+
+func TestAllowPodRestarts(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "job-abcde"},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{Name: "main", RestartCount: 1}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		options Options
+		allow   bool
+		failed  bool
+	}{
+		{"restarts", Options{}, false, true},
+		{"harness opt-out", Options{AllowPodRestarts: true}, false, false},
+		{"test opt-out", Options{}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &recordingT{T: t}
+			test, _ := newFakeTest(t, pod.DeepCopy())
+			test.t = rt
+			test.harness = &Harness{options: tt.options}
+			test.namespaces = []string{"ns"}
+			if tt.allow {
+				// Views share the opt-out of the test.
+				alice := &Test{parent: test, user: "alice"}
+				alice.AllowPodRestarts()
+			}
+
+			test.checkPodRestarts()
+			assert.Equal(t, tt.failed, test.inError)
+			assert.Equal(t, tt.failed, len(rt.errors) == 1, "errors: %v", rt.errors)
+		})
+	}
+}
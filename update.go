@@ -0,0 +1,217 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+)
+
+// apiResource describes where objects of a kind live in the API.
+type apiResource struct {
+	groupVersion schema.GroupVersion
+	resource     string
+	namespaced   bool
+}
+
+// resourceFor returns the API resource of the kinds managed by the harness.
+func resourceFor(obj runtime.Object) (*apiResource, error) {
+	switch obj.(type) {
+	case *v1.ConfigMap:
+		return &apiResource{v1.SchemeGroupVersion, "configmaps", true}, nil
+	case *v1.Namespace:
+		return &apiResource{v1.SchemeGroupVersion, "namespaces", false}, nil
+	case *v1.Node:
+		return &apiResource{v1.SchemeGroupVersion, "nodes", false}, nil
+	case *v1.PersistentVolumeClaim:
+		return &apiResource{v1.SchemeGroupVersion, "persistentvolumeclaims", true}, nil
+	case *v1.Pod:
+		return &apiResource{v1.SchemeGroupVersion, "pods", true}, nil
+	case *v1.Secret:
+		return &apiResource{v1.SchemeGroupVersion, "secrets", true}, nil
+	case *v1.Service:
+		return &apiResource{v1.SchemeGroupVersion, "services", true}, nil
+	case *v1.ServiceAccount:
+		return &apiResource{v1.SchemeGroupVersion, "serviceaccounts", true}, nil
+	case *appsv1.DaemonSet:
+		return &apiResource{appsv1.SchemeGroupVersion, "daemonsets", true}, nil
+	case *appsv1.Deployment:
+		return &apiResource{appsv1.SchemeGroupVersion, "deployments", true}, nil
+	case *appsv1.ReplicaSet:
+		return &apiResource{appsv1.SchemeGroupVersion, "replicasets", true}, nil
+	case *appsv1.StatefulSet:
+		return &apiResource{appsv1.SchemeGroupVersion, "statefulsets", true}, nil
+	case *batchv1.Job:
+		return &apiResource{batchv1.SchemeGroupVersion, "jobs", true}, nil
+	case *batchv1beta1.CronJob:
+		return &apiResource{batchv1beta1.SchemeGroupVersion, "cronjobs", true}, nil
+	case *networkingv1.Ingress:
+		return &apiResource{networkingv1.SchemeGroupVersion, "ingresses", true}, nil
+	case *networkingv1.IngressClass:
+		return &apiResource{networkingv1.SchemeGroupVersion, "ingressclasses", false}, nil
+	case *networkingv1.NetworkPolicy:
+		return &apiResource{networkingv1.SchemeGroupVersion, "networkpolicies", true}, nil
+	case *rbacv1.ClusterRole:
+		return &apiResource{rbacv1.SchemeGroupVersion, "clusterroles", false}, nil
+	case *rbacv1.ClusterRoleBinding:
+		return &apiResource{rbacv1.SchemeGroupVersion, "clusterrolebindings", false}, nil
+	case *rbacv1.Role:
+		return &apiResource{rbacv1.SchemeGroupVersion, "roles", true}, nil
+	case *rbacv1.RoleBinding:
+		return &apiResource{rbacv1.SchemeGroupVersion, "rolebindings", true}, nil
+	default:
+		return nil, fmt.Errorf("unsupported object type %T", obj)
+	}
+}
+
+func (test *Test) restClientFor(gv schema.GroupVersion) rest.Interface {
+	client := test.kubeClient
+	switch gv {
+	case appsv1.SchemeGroupVersion:
+		return client.AppsV1().RESTClient()
+	case batchv1.SchemeGroupVersion:
+		return client.BatchV1().RESTClient()
+	case batchv1beta1.SchemeGroupVersion:
+		return client.BatchV1beta1().RESTClient()
+	case networkingv1.SchemeGroupVersion:
+		return client.NetworkingV1().RESTClient()
+	case rbacv1.SchemeGroupVersion:
+		return client.RbacV1().RESTClient()
+	default:
+		return client.CoreV1().RESTClient()
+	}
+}
+
+// newObject returns a new, empty, object of the same type as obj.
+func newObject(obj runtime.Object) runtime.Object {
+	return reflect.New(reflect.TypeOf(obj).Elem()).Interface().(runtime.Object)
+}
+
+// setObject overwrites dst with src, both being of the same type.
+func setObject(dst, src runtime.Object) {
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(src).Elem())
+}
+
+// request returns a REST request targeting obj.
+func (test *Test) request(verb string, obj runtime.Object) (*rest.Request, error) {
+	res, err := resourceFor(obj)
+	if err != nil {
+		return nil, err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return test.restClientFor(res.groupVersion).
+		Verb(verb).
+		NamespaceIfScoped(accessor.GetNamespace(), res.namespaced).
+		Resource(res.resource).
+		Name(accessor.GetName()), nil
+}
+
+func objectName(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return fmt.Sprintf("%T", obj)
+	}
+	return accessor.GetName()
+}
+
+func (test *Test) update(obj runtime.Object, mutate func(runtime.Object)) error {
+	test.Debugf("updating %T %s", obj, objectName(obj))
+
+	updated := newObject(obj)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := newObject(obj)
+		req, err := test.request("GET", obj)
+		if err != nil {
+			return err
+		}
+		if err := req.Do(context.TODO()).Into(current); err != nil {
+			return err
+		}
+
+		mutate(current)
+
+		req, err = test.request("PUT", obj)
+		if err != nil {
+			return err
+		}
+		return req.Body(current).Do(context.TODO()).Into(updated)
+	})
+	if err != nil {
+		return fmt.Errorf("updating %s failed: %w", objectName(obj), err)
+	}
+
+	setObject(obj, updated)
+	return nil
+}
+
+// Update fetches the latest version of obj, calls mutate on it and updates the
+// object in the cluster. On conflict, ie. when the object has been modified
+// in between, the whole sequence is retried. mutate may be called several
+// times and is given an object of the same type as obj, eg.:
+//
+//	test.Update(deployment, func(o runtime.Object) {
+//	    d := o.(*appsv1.Deployment)
+//	    d.Spec.Template.Spec.Containers[0].Image = "nginx:1.19"
+//	})
+//
+// obj is then overwritten with the updated object. obj can be any of the kinds
+// the harness manages.
+func (test *Test) Update(obj runtime.Object, mutate func(runtime.Object)) {
+	test.err(test.update(obj, mutate))
+}
+
+func (test *Test) patch(obj runtime.Object, pt types.PatchType, data []byte) error {
+	test.Debugf("patching %T %s", obj, objectName(obj))
+
+	req, err := test.request("PATCH", obj)
+	if err != nil {
+		return err
+	}
+
+	patched := newObject(obj)
+	if err := req.SetHeader("Content-Type", string(pt)).Body(data).Do(context.TODO()).Into(patched); err != nil {
+		return fmt.Errorf("patching %s failed: %w", objectName(obj), err)
+	}
+
+	setObject(obj, patched)
+	return nil
+}
+
+// Patch applies a patch of the given type to obj in the cluster. obj is then
+// overwritten with the patched object. obj can be any of the kinds the harness
+// manages.
+func (test *Test) Patch(obj runtime.Object, pt types.PatchType, data []byte) {
+	test.err(test.patch(obj, pt, data))
+}
+
+// StrategicMergePatch applies a strategic merge patch, the patch type used by
+// kubectl patch by default, to obj.
+func (test *Test) StrategicMergePatch(obj runtime.Object, data []byte) {
+	test.err(test.patch(obj, types.StrategicMergePatchType, data))
+}
+
+// MergePatch applies a JSON merge patch (RFC 7386) to obj.
+func (test *Test) MergePatch(obj runtime.Object, data []byte) {
+	test.err(test.patch(obj, types.MergePatchType, data))
+}
+
+// JSONPatch applies a JSON patch (RFC 6902) to obj.
+func (test *Test) JSONPatch(obj runtime.Object, data []byte) {
+	test.err(test.patch(obj, types.JSONPatchType, data))
+}
@@ -0,0 +1,47 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestResourceFor(t *testing.T) {
+	tests := []struct {
+		obj        runtime.Object
+		resource   string
+		namespaced bool
+	}{
+		{&appsv1.Deployment{}, "deployments", true},
+		{&v1.Node{}, "nodes", false},
+		{&rbacv1.ClusterRoleBinding{}, "clusterrolebindings", false},
+		{&rbacv1.RoleBinding{}, "rolebindings", true},
+	}
+
+	for _, test := range tests {
+		res, err := resourceFor(test.obj)
+		assert.NoError(t, err)
+		assert.Equal(t, test.resource, res.resource)
+		assert.Equal(t, test.namespaced, res.namespaced)
+	}
+
+	_, err := resourceFor(&v1.Event{})
+	assert.Error(t, err)
+}
+
+func TestSetObject(t *testing.T) {
+	d := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "old", Labels: map[string]string{"a": "b"}}}
+
+	fresh := newObject(d)
+	assert.IsType(t, &appsv1.Deployment{}, fresh)
+	fresh.(*appsv1.Deployment).Name = "new"
+
+	setObject(d, fresh)
+	assert.Equal(t, "new", d.Name)
+	assert.Nil(t, d.Labels)
+}